@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// --- CACHE DAS RESPOSTAS DA API (ETAG) ---
+
+// Resposta guardada em disco para consultas condicionais (If-None-Match)
+type cachedResponse struct {
+	Url  string          `json:"url"`
+	ETag string          `json:"etag"`
+	Time string          `json:"time"`
+	Body json.RawMessage `json:"body"`
+}
+
+func getApiCacheDir() string {
+	return filepath.Join(getCacheDir(), "api")
+}
+
+func apiCachePath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(getApiCacheDir(), hex.EncodeToString(sum[:8])+".json")
+}
+
+func readApiCache(url string) *cachedResponse {
+	data, err := os.ReadFile(apiCachePath(url))
+	if err != nil {
+		return nil
+	}
+	var c cachedResponse
+	if json.Unmarshal(data, &c) != nil || c.Url != url || len(c.Body) == 0 {
+		return nil
+	}
+	return &c
+}
+
+func writeApiCache(url, etag string, body []byte) {
+	if !json.Valid(body) {
+		return
+	}
+	data, err := json.Marshal(cachedResponse{Url: url, ETag: etag, Time: time.Now().Format(time.RFC3339), Body: body})
+	if err == nil {
+		err = os.MkdirAll(getApiCacheDir(), 0700)
+	}
+	if err == nil {
+		err = os.WriteFile(apiCachePath(url), data, 0600)
+	}
+	if err != nil {
+		slog.Warn("falha ao guardar a resposta da API", "url", url, "erro", err)
+	}
+}
+
+// GET com If-None-Match quando já houver resposta guardada. Um 304 devolve o
+// corpo guardado com status 200; respostas 200 com ETag são guardadas.
+func cachedGet(ctx context.Context, url string, header http.Header) (int, http.Header, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	cached := readApiCache(url)
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second, CheckRedirect: httpsOnly}
+	resp, err := client.Do(req)
+	if err != nil {
+		slog.Error("falha ao consultar a release", "url", url, "erro", err)
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+	slog.Info("consulta da release", "url", url, "status", resp.StatusCode)
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return http.StatusOK, resp.Header, cached.Body, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			writeApiCache(url, etag, body)
+		}
+	}
+	return resp.StatusCode, resp.Header, body, nil
+}
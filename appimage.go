@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// --- FORMATO APPIMAGE ---
+
+const AppImageSuffix = ".AppImage"
+
+// Usa ~/Applications quando a pasta já existe (convenção do AppImageLauncher),
+// senão ~/.local/bin
+func getAppImageDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return os.TempDir()
+	}
+	apps := filepath.Join(home, "Applications")
+	if fi, err := os.Stat(apps); err == nil && fi.IsDir() {
+		return apps
+	}
+	return filepath.Join(home, ".local", "bin")
+}
+
+func getAppImagePath() string {
+	if st := loadState(); st.AppImagePath != "" {
+		return st.AppImagePath
+	}
+	return filepath.Join(getAppImageDir(), AppName+AppImageSuffix)
+}
+
+func getDataHome() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "share")
+}
+
+func getDesktopFilePath() string {
+	return filepath.Join(getDataHome(), "applications", AppName+".desktop")
+}
+
+func appImageInstalled() bool {
+	st := loadState()
+	if st.Format != FormatAppImage {
+		return false
+	}
+	_, err := os.Stat(getAppImagePath())
+	return err == nil
+}
+
+func installAppImage(ctx context.Context, release *GithubRelease, version string) bool {
+	fileName, url, err := findAssetUrl(release, AppImageSuffix)
+	if err != nil {
+		zenityError(trf("Esta versão não possui um arquivo AppImage.\n\n%v", err))
+		return false
+	}
+
+	dest := getAppImagePath()
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		zenityError(trf("Erro ao criar a pasta de destino:\n%v", err))
+		return false
+	}
+
+	// Baixa ao lado do destino para que a troca de versão seja atômica
+	part := dest + ".part"
+	if err := downloadFile(ctx, url, part); err != nil {
+		os.Remove(part)
+		showErrorOrCancelled(trf("Erro no download de %s:\n", fileName), err)
+		return false
+	}
+	if err := os.Chmod(part, 0755); err != nil {
+		os.Remove(part)
+		zenityError(trf("Erro ao marcar o AppImage como executável:\n%v", err))
+		return false
+	}
+	if err := os.Rename(part, dest); err != nil {
+		os.Remove(part)
+		zenityError(trf("Erro ao mover o AppImage:\n%v", err))
+		return false
+	}
+
+	st := loadState()
+	st.AppImagePath = dest
+	storeState(st)
+
+	if err := integrateAppImage(dest); err != nil {
+		slog.Warn("falha na integração com o menu de aplicativos", "erro", err)
+	}
+
+	recordInstall(FormatAppImage, version)
+	return true
+}
+
+// Extrai o .desktop e o ícone embutidos no AppImage para ~/.local/share,
+// apontando o Exec para o arquivo instalado
+func integrateAppImage(appImage string) error {
+	tmpDir, err := tempSubdir("appimage-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Os arquivos da raiz costumam ser links para usr/share, que é extraído junto
+	patterns := []string{"*.desktop", "*.png", "*.svg", ".DirIcon", "usr/share/applications/*", "usr/share/icons/*"}
+	for _, pattern := range patterns {
+		cmd := exec.Command(appImage, "--appimage-extract", pattern)
+		cmd.Dir = tmpDir
+		runLogged(cmd)
+	}
+	root := filepath.Join(tmpDir, "squashfs-root")
+
+	desktops, _ := filepath.Glob(filepath.Join(root, "*.desktop"))
+	if len(desktops) == 0 {
+		return errors.New(tr("nenhum arquivo .desktop encontrado no AppImage"))
+	}
+
+	iconName, err := installAppImageIcon(root)
+	if err != nil {
+		slog.Warn("ícone do AppImage não instalado", "erro", err)
+	}
+
+	return writeDesktopEntry(desktops[0], getDesktopFilePath(), appImage, iconName)
+}
+
+func installAppImageIcon(root string) (string, error) {
+	for _, ext := range []string{".svg", ".png"} {
+		icons, _ := filepath.Glob(filepath.Join(root, "*"+ext))
+		if len(icons) == 0 {
+			continue
+		}
+		size := "256x256"
+		if ext == ".svg" {
+			size = "scalable"
+		}
+		dest := filepath.Join(getDataHome(), "icons", "hicolor", size, "apps", AppName+ext)
+		if err := copyFile(icons[0], dest, 0644); err != nil {
+			return "", err
+		}
+		return AppName, nil
+	}
+	return "", errors.New(tr("nenhum ícone encontrado"))
+}
+
+func writeDesktopEntry(src, dest, execPath, iconName string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var b strings.Builder
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "Exec="):
+			args := ""
+			if fields := strings.Fields(strings.TrimPrefix(line, "Exec=")); len(fields) > 1 {
+				args = " " + strings.Join(fields[1:], " ")
+			}
+			line = fmt.Sprintf("Exec=\"%s\"%s", execPath, args)
+		case strings.HasPrefix(line, "TryExec="):
+			line = "TryExec=" + execPath
+		case strings.HasPrefix(line, "Icon=") && iconName != "":
+			line = "Icon=" + iconName
+		}
+		b.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(dest, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	runLogged(exec.Command("update-desktop-database", filepath.Dir(dest)))
+	return nil
+}
+
+func uninstallAppImage() bool {
+	path := getAppImagePath()
+	if _, err := os.Stat(path); err != nil {
+		return false
+	}
+	if err := os.Remove(path); err != nil {
+		return false
+	}
+	os.Remove(getDesktopFilePath())
+	for _, icon := range []string{
+		filepath.Join(getDataHome(), "icons", "hicolor", "scalable", "apps", AppName+".svg"),
+		filepath.Join(getDataHome(), "icons", "hicolor", "256x256", "apps", AppName+".png"),
+	} {
+		os.Remove(icon)
+	}
+	runLogged(exec.Command("update-desktop-database", filepath.Dir(getDesktopFilePath())))
+	return true
+}
+
+func copyFile(src, dest string, mode os.FileMode) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dest, data, mode)
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- FUNÇÕES AUR ---
+
+const AurGitUrl = "https://aur.archlinux.org/" + AppName + ".git"
+
+type aurStep struct {
+	Desc string
+	Name string
+	Args []string
+	Dir  string
+	Env  []string
+	Root bool // executado pelo processo auxiliar privilegiado
+	// Diretório onde o makepkg deixou o pacote; o arquivo é acrescentado aos
+	// argumentos na hora de executar
+	PkgDest string
+}
+
+func (s aurStep) String() string {
+	return s.Name + " " + strings.Join(s.Args, " ")
+}
+
+// Comandos do AUR aceitos pelo processo auxiliar privilegiado (o pacote
+// compilado é instalado com os argumentos do backend pacman)
+var (
+	aurToolsArgs = []string{"pacman", "-S", "--needed", "--noconfirm", "base-devel", "git"}
+	aurDepsArgs  = []string{"pacman", "-S", "--needed", "--asdeps", "--noconfirm"}
+)
+
+// Baixa o repositório do pacote no AUR para revisão, instalando antes o
+// base-devel e o git se estiverem faltando
+func aurPrepareSteps(srcDir string) []aurStep {
+	var steps []aurStep
+	if runLogged(exec.Command("pacman", "-Qq", "base-devel", "git")) != nil {
+		steps = append(steps, aurStep{
+			Desc: tr("Instalando base-devel e git"),
+			Name: aurToolsArgs[0],
+			Args: aurToolsArgs[1:],
+			Root: true,
+		})
+	}
+	return append(steps, aurStep{
+		Desc: tr("Clonando AUR"),
+		Name: "git",
+		Args: []string{"clone", AurGitUrl, srcDir},
+	})
+}
+
+// Compila o repositório já revisado. Só dependências dos repositórios oficiais
+// são instaladas automaticamente; as do AUR teriam PKGBUILDs nunca revisados,
+// então a instalação para e pede que sejam instaladas antes. O makepkg roda
+// sem -s/-i, só como usuário; as dependências e o pacote compilado são
+// instalados pelo processo auxiliar, com a mesma autenticação da execução.
+func aurBuildSteps(srcDir string) ([]aurStep, error) {
+	var steps []aurStep
+
+	repoDeps, aurDeps := splitMissingDepends(srcinfoDepends(srcDir))
+	if len(aurDeps) > 0 {
+		return nil, fmt.Errorf(tr("dependências que só existem no AUR: %s.\nInstale-as antes (revisando o PKGBUILD de cada uma) e tente novamente"), strings.Join(aurDeps, ", "))
+	}
+	if len(repoDeps) > 0 {
+		steps = append(steps, aurStep{
+			Desc: tr("Instalando dependências"),
+			Name: aurDepsArgs[0],
+			Args: append(append([]string{}, aurDepsArgs[1:]...), repoDeps...),
+			Root: true,
+		})
+	}
+
+	// PKGDEST e PKGEXT do ambiente valem mais que o makepkg.conf: o pacote
+	// fica no diretório temporário privado, onde o auxiliar aceita arquivos
+	pkgDest := filepath.Join(filepath.Dir(srcDir), "pkg")
+	if err := os.MkdirAll(pkgDest, 0755); err != nil {
+		return nil, err
+	}
+	pacman := nativeBackends["pacman"]
+	return append(steps,
+		aurStep{
+			Desc: tr("Compilando"),
+			Name: "makepkg",
+			Args: []string{"--noconfirm", "--nocolor"},
+			Dir:  srcDir,
+			Env:  []string{"PKGDEST=" + pkgDest, "PKGEXT=" + pacman.Suffix},
+		},
+		aurStep{
+			Desc:    tr("Instalando o pacote"),
+			Name:    pacman.InstallArgs[0],
+			Args:    pacman.InstallArgs[1:],
+			Root:    true,
+			PkgDest: pkgDest,
+		}), nil
+}
+
+// Pacote principal gerado pelo makepkg (o -debug, se houver, fica de fora)
+func builtPackage(dir string) (string, error) {
+	files, _ := filepath.Glob(filepath.Join(dir, AppName+"-[0-9]*"+nativeBackends["pacman"].Suffix))
+	if len(files) != 1 {
+		return "", fmt.Errorf(tr("pacote compilado não encontrado em %s"), dir)
+	}
+	return files[0], nil
+}
+
+// Separa as dependências ainda não satisfeitas (pacman -T também considera os
+// "provides") entre as encontradas nos repositórios e as que não estão neles
+func splitMissingDepends(deps []string) (repo, aur []string) {
+	if len(deps) == 0 {
+		return nil, nil
+	}
+	out, _ := outputLogged(exec.Command("pacman", append([]string{"-T"}, deps...)...))
+	for _, dep := range strings.Fields(string(out)) {
+		if runLogged(exec.Command("pacman", "-Sp", "--print-format", "%n", dep)) == nil {
+			repo = append(repo, dep)
+		} else {
+			aur = append(aur, dep)
+		}
+	}
+	return repo, aur
+}
+
+// Lê depends e makedepends do .SRCINFO, sem as restrições de versão
+func srcinfoDepends(srcDir string) []string {
+	data, err := os.ReadFile(filepath.Join(srcDir, ".SRCINFO"))
+	if err != nil {
+		return nil
+	}
+
+	var deps []string
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), " = ")
+		if !ok || (key != "depends" && key != "makedepends") {
+			continue
+		}
+		if i := strings.IndexAny(value, "<>="); i != -1 {
+			value = value[:i]
+		}
+		deps = append(deps, value)
+	}
+	return deps
+}
+
+func installViaAUR(ctx context.Context, distro DistroInfo, action, fromVersion, version string) {
+	msg := trf(
+		"Sistema <b>Arch Linux</b> detectado.\n\n"+
+			"O <b>%s</b> será instalado diretamente do <b>AUR</b> para resolver as dependências automaticamente.\n\n"+
+			"Antes da compilação você poderá revisar o PKGBUILD e os demais arquivos do pacote.\n"+
+			"Deseja continuar?", AppPrettyName)
+
+	if !zenityQuestion(msg) {
+		exit(0)
+	}
+
+	// O makepkg se recusa a rodar como root, então é preciso um mecanismo de elevação
+	priv, err := detectPrivilege()
+	if err == nil && len(priv.Prefix) == 0 {
+		err = errors.New(tr("o makepkg não pode ser executado como root; rode o instalador como usuário comum"))
+	}
+	if err != nil {
+		zenityError(escapeMarkup(err.Error()))
+		exit(1)
+	}
+
+	buildDir, err := tempSubdir("aur-build-")
+	if err != nil {
+		zenityError(trf("Erro ao criar diretório temporário: %v", err))
+		exit(1)
+	}
+	defer os.RemoveAll(buildDir)
+	srcDir := filepath.Join(buildDir, AppName)
+
+	logPath := newBuildLogPath()
+	logFile, err := createBuildLog(logPath)
+	if err != nil {
+		zenityError(trf("Erro ao criar o log da instalação: %v", err))
+		exit(1)
+	}
+
+	stop := startPulsate(tr("Preparando..."), trf("Baixando o PKGBUILD do %s no AUR...", AppPrettyName))
+	err = runAurSteps(ctx, aurPrepareSteps(srcDir), logFile)
+	stop()
+	if isCancelled(err) {
+		logFile.Close()
+		exit(1)
+	}
+	if err != nil {
+		logFile.Close()
+		zenityError(trf("Falha ao baixar o pacote do AUR:\n%s\n\nO log completo foi salvo em:\n<small>%s</small>",
+			escapeMarkup(err.Error()), escapeMarkup(logPath)))
+		exit(1)
+	}
+
+	if !reviewPKGBUILD(srcDir) {
+		fmt.Fprintln(logFile, "\n>>> "+tr("Compilação cancelada na revisão do PKGBUILD."))
+		logFile.Close()
+		exit(0)
+	}
+
+	buildSteps, err := aurBuildSteps(srcDir)
+	if err != nil {
+		fmt.Fprintf(logFile, "\n>>> %s\n", err)
+		logFile.Close()
+		recordHistory(action, FormatNative, fromVersion, version, err)
+		zenityError(escapeMarkup(err.Error()))
+		exit(1)
+	}
+
+	viewer := openLogViewer(trf("Instalação via AUR: %s", AppPrettyName), logFile)
+
+	runErr := runAurSteps(ctx, buildSteps, viewer)
+	if runErr == nil && !aurPackageInstalled() {
+		runErr = fmt.Errorf(tr("o pacote %s não aparece como instalado no pacman"), AppName)
+	}
+
+	if runErr == nil {
+		fmt.Fprintln(viewer, "\n>>> "+tr("SUCESSO! Pacote instalado."))
+	} else {
+		fmt.Fprintf(viewer, "\n>>> %s\n", trf("FALHA NA INSTALAÇÃO: %s", runErr))
+	}
+	viewer.Close()
+	recordHistory(action, FormatNative, fromVersion, version, runErr)
+
+	if isCancelled(runErr) {
+		showCancelled()
+		exit(1)
+	}
+	if runErr != nil {
+		zenityError(trf("Falha na instalação via AUR:\n%s\n\nO log completo foi salvo em:\n<small>%s</small>",
+			escapeMarkup(runErr.Error()), escapeMarkup(logPath)))
+		exit(1)
+	}
+
+	recordInstall(FormatNative, version)
+	if zenityQuestionCustomTitle(tr("Instalação do AUR finalizada.\nDeseja abrir agora?"), tr("Sucesso")) {
+		openApplication()
+	}
+	exit(0)
+}
+
+func aurPackageInstalled() bool {
+	return runLogged(exec.Command("pacman", "-Qi", AppName)) == nil
+}
+
+// Executa os passos em ordem, parando no primeiro que falhar. Os passos como
+// root são transações do pacman e nunca são interrompidos no meio; o git e o
+// makepkg são encerrados no cancelamento.
+func runAurSteps(ctx context.Context, steps []aurStep, out io.Writer) error {
+	for i, step := range steps {
+		if err := ctx.Err(); err != nil {
+			fmt.Fprintln(out, "\n==> "+tr("Cancelado."))
+			return err
+		}
+		if step.PkgDest != "" {
+			pkg, err := builtPackage(step.PkgDest)
+			if err != nil {
+				return err
+			}
+			step.Args = append(append([]string{}, step.Args...), pkg)
+		}
+		fmt.Fprintf(out, "\n==> [%d/%d] %s\n$ %s\n", i+1, len(steps), step.Desc, step)
+
+		var err error
+		if step.Root {
+			err = privilegedRunner{}.Run(ctx, out, step.Name, step.Args...)
+		} else {
+			err = runUserStep(ctx, step, out)
+		}
+		if ctx.Err() != nil && !step.Root {
+			fmt.Fprintln(out, "\n==> "+tr("Cancelado."))
+			return ctx.Err()
+		}
+		if err != nil {
+			fmt.Fprintf(out, "==> %s\n", err)
+			return fmt.Errorf(tr("o passo \"%s\" falhou: %v"), step.Desc, err)
+		}
+		fmt.Fprintf(out, "==> %s\n", trf("código de saída: %d", 0))
+	}
+	return nil
+}
+
+func runUserStep(ctx context.Context, step aurStep, out io.Writer) error {
+	defer trackCommand(false)()
+	cmd := exec.CommandContext(ctx, step.Name, step.Args...)
+	killGroupOnCancel(cmd)
+	cmd.Dir = step.Dir
+	cmd.Env = append(os.Environ(), step.Env...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	return runLogged(cmd)
+}
+
+// --- JANELA DE LOG ---
+
+func newBuildLogPath() string {
+	return filepath.Join(getStateDir(), "logs", "aur-build-"+time.Now().Format("20060102-150405")+".log")
+}
+
+var ansiRe = regexp.MustCompile(`\x1b\[[0-9;?]*[a-zA-Z]`)
+
+// Espelha a saída num arquivo de log e numa janela zenity --text-info
+type logViewer struct {
+	mu    sync.Mutex
+	file  *os.File
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+}
+
+func createBuildLog(logPath string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(logPath)
+}
+
+func openLogViewer(title string, file *os.File) *logViewer {
+	v := &logViewer{file: file}
+	v.cmd = exec.Command("zenity", "--text-info", "--auto-scroll",
+		"--title="+title, "--width=800", "--height=500")
+	if stdin, err := v.cmd.StdinPipe(); err == nil && v.cmd.Start() == nil {
+		v.stdin = stdin
+	}
+	return v
+}
+
+func (v *logViewer) Write(p []byte) (int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	clean := ansiRe.ReplaceAll(p, nil)
+	v.file.Write(clean)
+	if v.stdin != nil {
+		// Se o usuário fechou a janela, segue gravando só no arquivo
+		if _, err := v.stdin.Write(clean); err != nil {
+			v.stdin = nil
+		}
+	}
+	return len(p), nil
+}
+
+// Fecha o log e espera o usuário fechar a janela
+func (v *logViewer) Close() error {
+	v.mu.Lock()
+	if v.stdin != nil {
+		v.stdin.Close()
+		v.stdin = nil
+	}
+	v.mu.Unlock()
+
+	if v.cmd.Process != nil {
+		v.cmd.Wait()
+	}
+	return v.file.Close()
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// --- GERENCIADORES DE PACOTES NATIVOS ---
+
+type NativeBackend struct {
+	Manager     string   // nome do gerenciador (apt, dnf, eopkg...)
+	Pretty      string   // nome exibido nas mensagens
+	Suffix      string   // extensão do pacote publicado na release ("" = não há pacote nativo)
+	InstallArgs []string // instala um arquivo local (o arquivo é o último argumento)
+	RemoveArgs  []string
+	// Instala um arquivo de versão mais antiga que a instalada (nil = InstallArgs)
+	RollbackArgs []string
+	NeedsRoot    bool
+
+	queryVersion func() string
+}
+
+func distroIs(d DistroInfo, names ...string) bool {
+	for _, n := range names {
+		if strings.Contains(d.ID, n) || strings.Contains(d.IDLike, n) {
+			return true
+		}
+	}
+	return false
+}
+
+func isArchFamily(d DistroInfo) bool {
+	return distroIs(d, "arch", "manjaro", "cachyos")
+}
+
+func isSuseFamily(d DistroInfo) bool {
+	return distroIs(d, "suse")
+}
+
+var nativeBackends = map[string]*NativeBackend{
+	"pacman": {
+		Manager:      "pacman",
+		Pretty:       "Arch Linux",
+		Suffix:       ".pkg.tar.zst",
+		InstallArgs:  []string{"pacman", "-U", "--noconfirm"},
+		RemoveArgs:   []string{"pacman", "-Rns", "--noconfirm", AppName},
+		NeedsRoot:    true,
+		queryVersion: func() string { return firstFieldVersion("pacman", "-Q", AppName) },
+	},
+	"apt": {
+		Manager:      "apt",
+		Pretty:       "Debian/Ubuntu",
+		Suffix:       ".deb",
+		InstallArgs:  []string{"apt", "-o", "APT::Status-Fd=1", "install", "-y"},
+		RollbackArgs: []string{"apt", "-o", "APT::Status-Fd=1", "install", "-y", "--allow-downgrades"},
+		RemoveArgs:   []string{"apt", "remove", "-y", AppName},
+		NeedsRoot:    true,
+		queryVersion: func() string { return commandVersion("dpkg-query", "-W", "-f=${Version}", AppName) },
+	},
+	"dnf": {
+		Manager:      "dnf",
+		Pretty:       "Fedora",
+		Suffix:       ".rpm",
+		InstallArgs:  []string{"dnf", "install", "-y"},
+		RollbackArgs: []string{"dnf", "downgrade", "-y"},
+		RemoveArgs:   []string{"dnf", "remove", "-y", AppName},
+		NeedsRoot:    true,
+		queryVersion: rpmVersion,
+	},
+	"zypper": {
+		Manager:      "zypper",
+		Pretty:       "openSUSE",
+		Suffix:       ".rpm",
+		InstallArgs:  []string{"zypper", "--non-interactive", "install", "-y", "--allow-unsigned-rpm"},
+		RollbackArgs: []string{"zypper", "--non-interactive", "install", "-y", "--allow-unsigned-rpm", "--oldpackage"},
+		RemoveArgs:   []string{"zypper", "--non-interactive", "remove", "-y", AppName},
+		NeedsRoot:    true,
+		queryVersion: rpmVersion,
+	},
+	"eopkg": {
+		Manager:      "eopkg",
+		Pretty:       "Solus",
+		Suffix:       ".eopkg",
+		InstallArgs:  []string{"eopkg", "install", "-y"},
+		RemoveArgs:   []string{"eopkg", "remove", "-y", AppName},
+		NeedsRoot:    true,
+		queryVersion: eopkgVersion,
+	},
+	"xbps": {
+		Manager:      "xbps",
+		Pretty:       "Void Linux",
+		Suffix:       ".xbps",
+		InstallArgs:  []string{"xbps-install", "-y"},
+		RollbackArgs: []string{"xbps-install", "-y", "-f"},
+		RemoveArgs:   []string{"xbps-remove", "-y", AppName},
+		NeedsRoot:    true,
+		queryVersion: xbpsVersion,
+	},
+	"apk": {
+		Manager:      "apk",
+		Pretty:       "Alpine Linux",
+		Suffix:       ".apk",
+		InstallArgs:  []string{"apk", "add", "--allow-untrusted"},
+		RemoveArgs:   []string{"apk", "del", AppName},
+		NeedsRoot:    true,
+		queryVersion: apkVersion,
+	},
+	"emerge": {
+		Manager:      "emerge",
+		Pretty:       "Gentoo",
+		RemoveArgs:   []string{"emerge", "--unmerge", AppName},
+		NeedsRoot:    true,
+		queryVersion: gentooVersion,
+	},
+	"nix": {
+		Manager:      "nix",
+		Pretty:       "NixOS",
+		RemoveArgs:   []string{"nix-env", "-e", AppName},
+		NeedsRoot:    false,
+		queryVersion: nixVersion,
+	},
+}
+
+// Ordem usada quando o /etc/os-release não identifica a distribuição
+var backendBinaries = []struct {
+	bin     string
+	manager string
+}{
+	{"apt", "apt"},
+	{"dnf", "dnf"},
+	{"zypper", "zypper"},
+	{"pacman", "pacman"},
+	{"eopkg", "eopkg"},
+	{"xbps-install", "xbps"},
+	{"apk", "apk"},
+	{"emerge", "emerge"},
+	{"nix-env", "nix"},
+}
+
+func detectNativeBackend(d DistroInfo) *NativeBackend {
+	switch {
+	case isArchFamily(d):
+		return nativeBackends["pacman"]
+	case distroIs(d, "debian", "ubuntu"):
+		return nativeBackends["apt"]
+	case isSuseFamily(d):
+		return nativeBackends["zypper"]
+	case distroIs(d, "fedora", "bazzite"):
+		return nativeBackends["dnf"]
+	case distroIs(d, "solus"):
+		return nativeBackends["eopkg"]
+	case distroIs(d, "void"):
+		return nativeBackends["xbps"]
+	case distroIs(d, "alpine"):
+		return nativeBackends["apk"]
+	case distroIs(d, "gentoo"):
+		return nativeBackends["emerge"]
+	case distroIs(d, "nixos"):
+		return nativeBackends["nix"]
+	}
+
+	for _, b := range backendBinaries {
+		if _, err := exec.LookPath(b.bin); err == nil {
+			return nativeBackends[b.manager]
+		}
+	}
+	return nil
+}
+
+// Monta o comando de instalação. O xbps não instala arquivos soltos, apenas
+// pacotes de um repositório, então o arquivo é movido para um diretório
+// próprio e indexado com xbps-rindex.
+func (b *NativeBackend) installArgs(file string) (args []string, cleanup func(), err error) {
+	if b.Manager != "xbps" {
+		return append(append([]string{}, b.InstallArgs...), file), func() {}, nil
+	}
+
+	dir, err := tempSubdir("xbps-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup = func() { os.RemoveAll(dir) }
+
+	local := filepath.Join(dir, filepath.Base(file))
+	if err := os.Rename(file, local); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	if out, err := combinedLogged(exec.Command("xbps-rindex", "-a", local)); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf(tr("falha ao indexar o pacote: %s"), strings.TrimSpace(string(out)))
+	}
+	if err := verifyPrivate(dir); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	args = append(append([]string{}, b.InstallArgs...), "--repository", dir, AppName)
+	return args, cleanup, nil
+}
+
+// Como installArgs, mas permitindo instalar uma versão mais antiga
+func (b *NativeBackend) rollbackArgs(file string) (args []string, cleanup func(), err error) {
+	args, cleanup, err = b.installArgs(file)
+	if err != nil || b.RollbackArgs == nil {
+		return args, cleanup, err
+	}
+	rest := args[len(b.InstallArgs):]
+	return append(append([]string{}, b.RollbackArgs...), rest...), cleanup, nil
+}
+
+// --- CONSULTA DE VERSÃO INSTALADA ---
+
+func queryNativeVersion(d DistroInfo) string {
+	b := detectNativeBackend(d)
+	if b == nil || b.queryVersion == nil {
+		return ""
+	}
+	return stripEpoch(b.queryVersion())
+}
+
+// Remove a "epoch" (ex: "1:1.3.1.4-1" vira "1.3.1.4-1")
+func stripEpoch(v string) string {
+	if idx := strings.Index(v, ":"); idx != -1 {
+		return v[idx+1:]
+	}
+	return v
+}
+
+func commandVersion(name string, args ...string) string {
+	out, err := outputLogged(exec.Command(name, args...))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func firstFieldVersion(name string, args ...string) string {
+	parts := strings.Fields(commandVersion(name, args...))
+	if len(parts) >= 2 {
+		return parts[1]
+	}
+	return ""
+}
+
+func rpmVersion() string {
+	return commandVersion("rpm", "-q", "--qf", "%{VERSION}-%{RELEASE}", AppName)
+}
+
+var eopkgVersionRe = regexp.MustCompile(`version:\s*([^,\s]+)`)
+
+func eopkgVersion() string {
+	m := eopkgVersionRe.FindStringSubmatch(commandVersion("eopkg", "info", AppName))
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// Saída no formato "tac-writer-1.3.1_1"
+func xbpsVersion() string {
+	v := commandVersion("xbps-query", "-p", "pkgver", AppName)
+	v = strings.TrimPrefix(v, AppName+"-")
+	return strings.ReplaceAll(v, "_", "-")
+}
+
+// Saída no formato "tac-writer-1.3.1-r0 x86_64 {tac-writer} ... [installed]"
+func apkVersion() string {
+	parts := strings.Fields(commandVersion("apk", "list", "--installed", AppName))
+	if len(parts) == 0 || !strings.HasPrefix(parts[0], AppName+"-") {
+		return ""
+	}
+	return strings.TrimPrefix(parts[0], AppName+"-")
+}
+
+// O Portage registra os pacotes instalados em /var/db/pkg/<categoria>/<nome>-<versão>
+func gentooVersion() string {
+	matches, _ := filepath.Glob(filepath.Join("/var/db/pkg", "*", AppName+"-[0-9]*"))
+	if len(matches) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(filepath.Base(matches[0]), AppName+"-")
+}
+
+// Saída no formato "tac-writer-1.3.1"
+func nixVersion() string {
+	v := commandVersion("nix-env", "-q", AppName)
+	if !strings.HasPrefix(v, AppName+"-") {
+		return ""
+	}
+	return strings.TrimPrefix(v, AppName+"-")
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Nomes de asset que quebrariam um comando montado como texto para o shell
+var hostileNames = []string{
+	"tac-writer_1.0_amd64",
+	"tac'writer",
+	`tac"writer`,
+	"tac$(touch pwned)writer",
+	"tac`id`writer",
+	"tac;rm -rf ~;writer",
+	"tac writer com espaços",
+	"../../../etc/tac-writer",
+	"-rf tac-writer",
+	"--allow-unauthenticated",
+}
+
+// Remove o diretório temporário criado pelos testes
+func TestMain(m *testing.M) {
+	code := m.Run()
+	cleanupTemp()
+	os.Exit(code)
+}
+
+func TestSafeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"tac-writer_1.0_amd64.deb", "tac-writer_1.0_amd64.deb"},
+		{"tac'writer.deb", "tac'writer.deb"},
+		{`tac"writer.deb`, `tac"writer.deb`},
+		{"tac$(touch pwned)writer.deb", "tac$(touch pwned)writer.deb"},
+		{"tac;rm -rf ~;writer.deb", "tac;rm -rf ~;writer.deb"},
+		{"tac writer.deb", "tac writer.deb"},
+		{"../../../etc/passwd", "passwd"},
+		{"sub/dir/tac.rpm", "tac.rpm"},
+		{"/abs/tac.rpm", "tac.rpm"},
+		{"-rf.deb", "rf.deb"},
+		{"--allow-unauthenticated.deb", "allow-unauthenticated.deb"},
+		{"..", AppName + "-download"},
+		{"../", AppName + "-download"},
+		{"", AppName + "-download"},
+		{"---", AppName + "-download"},
+		{".hidden.deb", "hidden.deb"},
+	}
+	for _, tt := range tests {
+		got := safeFileName(tt.name)
+		if got != tt.want {
+			t.Errorf("safeFileName(%q) = %q, esperado %q", tt.name, got, tt.want)
+		}
+		if strings.Contains(got, "/") || strings.HasPrefix(got, "-") {
+			t.Errorf("safeFileName(%q) = %q ainda tem diretório ou começa com -", tt.name, got)
+		}
+	}
+}
+
+// O arquivo vai como um único argumento, o último, logo depois dos
+// argumentos fixos do gerenciador
+func TestInstallArgsHostileNames(t *testing.T) {
+	for manager, b := range nativeBackends {
+		if b.Suffix == "" || manager == "xbps" {
+			continue
+		}
+		for _, name := range hostileNames {
+			file, err := tempPath(name + b.Suffix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(file, []byte("pacote"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			for kind, build := range map[string]func(string) ([]string, func(), error){
+				"install":  b.installArgs,
+				"rollback": b.rollbackArgs,
+			} {
+				args, cleanup, err := build(file)
+				if err != nil {
+					t.Fatalf("%s %s(%q): %v", manager, kind, name, err)
+				}
+				cleanup()
+
+				prefix := b.InstallArgs
+				if kind == "rollback" && b.RollbackArgs != nil {
+					prefix = b.RollbackArgs
+				}
+				if !equalArgs(args, append(append([]string{}, prefix...), file)) {
+					t.Errorf("%s %s(%q) = %q", manager, kind, name, args)
+				}
+				if err := allowedRootCommand(args); err != nil {
+					t.Errorf("%s %s(%q) recusado pelo auxiliar: %v", manager, kind, name, err)
+				}
+			}
+			os.Remove(file)
+		}
+	}
+}
+
+// O xbps instala de um repositório local: o diretório vai num único argumento
+// depois de --repository e o arquivo mantém o nome dentro dele
+func TestInstallArgsXbps(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "xbps-rindex"), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	b := nativeBackends["xbps"]
+	for _, name := range hostileNames {
+		for kind, build := range map[string]func(string) ([]string, func(), error){
+			"install":  b.installArgs,
+			"rollback": b.rollbackArgs,
+		} {
+			file, err := tempPath(name + b.Suffix)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(file, []byte("pacote"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			args, cleanup, err := build(file)
+			if err != nil {
+				t.Fatalf("xbps %s(%q): %v", kind, name, err)
+			}
+
+			prefix := b.InstallArgs
+			if kind == "rollback" {
+				prefix = b.RollbackArgs
+			}
+			rest := args[len(prefix):]
+			if !equalArgs(args[:len(prefix)], prefix) || len(rest) != 3 || rest[0] != "--repository" || rest[2] != AppName {
+				t.Errorf("xbps %s(%q) = %q", kind, name, args)
+			} else if _, err := os.Stat(filepath.Join(rest[1], filepath.Base(file))); err != nil {
+				t.Errorf("xbps %s(%q): pacote fora do repositório: %v", kind, name, err)
+			}
+			if err := allowedRootCommand(args); err != nil {
+				t.Errorf("xbps %s(%q) recusado pelo auxiliar: %v", kind, name, err)
+			}
+			cleanup()
+		}
+	}
+}
+
+// Argumentos a mais ou fora do diretório temporário não passam pelo auxiliar
+func TestAllowedRootCommandRejects(t *testing.T) {
+	file, err := tempPath("tac-writer.deb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("pacote"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file)
+
+	apt := nativeBackends["apt"].InstallArgs
+	tests := [][]string{
+		nil,
+		{"sh", "-c", "id"},
+		append(append([]string{}, apt...), "--allow-unauthenticated", file),
+		append(append([]string{}, apt...), file, file),
+		append(append([]string{}, apt...), "-rf tac-writer.deb"),
+		append(append([]string{}, apt...), "../tac-writer.deb"),
+		append(append([]string{}, apt...), filepath.Dir(file)+"/../tac-writer.deb"),
+		append(append([]string{}, apt...), "/etc/passwd"),
+		append(append([]string{}, nativeBackends["xbps"].InstallArgs...), "--repository", "/etc", AppName),
+		aurDepsArgs,
+		append(append([]string{}, aurDepsArgs...), "--config=/tmp/pacman.conf", "gtk4"),
+		append(append([]string{}, aurDepsArgs...), "gtk4", "-Syu"),
+		append(append([]string{}, aurDepsArgs...), "gtk4;id"),
+		append(append([]string{}, aurToolsArgs...), "python"),
+	}
+	for _, argv := range tests {
+		if err := allowedRootCommand(argv); err == nil {
+			t.Errorf("allowedRootCommand(%q) aceito", argv)
+		}
+	}
+}
+
+// Ferramentas e dependências dos repositórios pedidas pelo fluxo do AUR
+func TestAllowedRootCommandAur(t *testing.T) {
+	tests := [][]string{
+		aurToolsArgs,
+		append(append([]string{}, aurDepsArgs...), "gtk4"),
+		append(append([]string{}, aurDepsArgs...), "python-gobject", "libadwaita", "gtk+3", "lib32-glibc"),
+	}
+	for _, argv := range tests {
+		if err := allowedRootCommand(argv); err != nil {
+			t.Errorf("allowedRootCommand(%q): %v", argv, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// --- CANCELAMENTO ---
+
+// Downloads e comandos do usuário são interrompidos na hora (o processo e
+// todos os filhos recebem SIGTERM). Transações do gerenciador de pacotes
+// nunca são interrompidas: o cancelamento só vale depois que terminarem, para
+// não deixar o dpkg/rpm no meio de uma operação.
+
+var (
+	activeMu           sync.Mutex
+	activeCommands     int
+	activeTransactions int
+)
+
+// Registra um comando em execução; use com defer trackCommand(...)()
+func trackCommand(transaction bool) func() {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	if transaction {
+		activeTransactions++
+	} else {
+		activeCommands++
+	}
+	return func() {
+		activeMu.Lock()
+		defer activeMu.Unlock()
+		if transaction {
+			activeTransactions--
+		} else {
+			activeCommands--
+		}
+	}
+}
+
+// Espera as transações terminarem e dá aos demais comandos até timeout para
+// encerrarem depois do SIGTERM
+func waitForCommands(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	warned := false
+	for {
+		activeMu.Lock()
+		transactions, commands := activeTransactions, activeCommands
+		activeMu.Unlock()
+
+		if transactions == 0 && (commands == 0 || time.Now().After(deadline)) {
+			return
+		}
+		if transactions > 0 && !warned {
+			fmt.Fprintln(os.Stderr, tr("Aguardando o gerenciador de pacotes concluir a operação atual..."))
+			warned = true
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Faz o cancelamento do contexto encerrar o comando e todos os seus filhos
+// (pip, git, flatpak...), não só o processo principal
+func killGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+	cmd.WaitDelay = 5 * time.Second
+}
+
+// Protege um comando como root do Ctrl+C (e Ctrl+Z) digitado no terminal do
+// instalador. Sem terminal, ele vai para outro grupo de processos e os sinais
+// do teclado não chegam a ele. Com terminal (sudo ou agente de texto do
+// pkexec/run0 pedindo a senha) ele precisa ficar no grupo em primeiro plano
+// para ler a senha, e não há como saber quando a autenticação terminou; então
+// o terminal deixa de gerar sinais enquanto o comando roda, o que também
+// impede abortar a pergunta da senha com Ctrl+C. Devolve a função que
+// restaura o terminal.
+func shieldFromTerminal(cmd *exec.Cmd, tty bool) (restore func()) {
+	if !tty {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		return func() {}
+	}
+	cmd.Stdin = os.Stdin
+	return disableTerminalSignals(os.Stdin.Fd())
+}
+
+// Desliga o ISIG do terminal: Ctrl+C, Ctrl+Z e Ctrl+\ viram caracteres comuns
+func disableTerminalSignals(fd uintptr) (restore func()) {
+	var old syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(&old))); errno != 0 {
+		return func() {}
+	}
+	t := old
+	t.Lflag &^= syscall.ISIG
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCSETS, uintptr(unsafe.Pointer(&t))); errno != 0 {
+		return func() {}
+	}
+	return func() {
+		syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCSETS, uintptr(unsafe.Pointer(&old)))
+	}
+}
+
+func isCancelled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
+func showCancelled() {
+	zenityInfo(tr("Operação cancelada."))
+}
+
+// Mostra o erro, ou apenas avisa do cancelamento quando foi o caso
+func showErrorOrCancelled(prefix string, err error) {
+	if isCancelled(err) {
+		showCancelled()
+		return
+	}
+	zenityError(prefix + err.Error())
+}
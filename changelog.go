@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// --- NOVIDADES ACUMULADAS ENTRE VERSÕES ---
+
+// Espaço total das notas de várias versões no diálogo de atualização e
+// quantas versões aparecem com as notas; o texto completo fica na janela de
+// "Ver todas as novidades"
+const (
+	maxChangelog       = 1200
+	maxVersionsInNotes = 3
+)
+
+var headingLevelRe = regexp.MustCompile(`^(#{1,4})(\s)`)
+
+// Releases publicadas depois de from até to (inclusive), da mais nova para a
+// mais antiga
+func releasesBetween(releases []GithubRelease, from, to string) []GithubRelease {
+	var between []GithubRelease
+	for _, r := range releases {
+		v := strings.TrimPrefix(r.TagName, "v")
+		if r.Draft || r.Prerelease {
+			continue
+		}
+		if compareVersions(v, from) > 0 && compareVersions(v, to) <= 0 {
+			between = append(between, r)
+		}
+	}
+	sort.SliceStable(between, func(i, j int) bool {
+		return compareVersions(strings.TrimPrefix(between[i].TagName, "v"), strings.TrimPrefix(between[j].TagName, "v")) > 0
+	})
+	return between
+}
+
+// Resumo das notas com um título por versão. As maxVersionsInNotes mais
+// novas dividem maxChangelog e são cortadas com o link da própria release;
+// as mais antigas aparecem só com o título. Os títulos das notas descem dois
+// níveis para ficarem abaixo do da versão.
+func formatChangelog(releases []GithubRelease, page string) string {
+	withNotes := len(releases)
+	if withNotes > maxVersionsInNotes {
+		withNotes = maxVersionsInNotes
+	}
+	budget := maxChangelog / withNotes
+
+	var parts []string
+	var older []string
+	for i := range releases {
+		r := &releases[i]
+		version := strings.TrimPrefix(r.TagName, "v")
+		if i >= withNotes {
+			older = append(older, "• "+escapeMarkup(version+" — "+formatDate(r.PublishedAt)))
+			continue
+		}
+		body := strings.TrimSpace(r.Body)
+		if body == "" {
+			body = tr("Nenhuma descrição fornecida.")
+		}
+		title := fmt.Sprintf("# %s — %s\n\n", version, formatDate(r.PublishedAt))
+		parts = append(parts, markdownToPangoLimit(title+demoteHeadings(body), releasePageUrl(r), budget+utf8.RuneCountInString(title)))
+	}
+
+	if len(older) > 0 {
+		text := "<b>" + tr("Versões anteriores:") + "</b>\n" + strings.Join(older, "\n")
+		if page != "" {
+			text += "\n\n" + pangoLink(page, tr("… ver as notas completas"))
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Desce os títulos dois níveis, sem mexer nos blocos de código
+func demoteHeadings(md string) string {
+	lines := strings.Split(md, "\n")
+	inCode := false
+	for i, line := range lines {
+		if isCodeFence(line) {
+			inCode = !inCode
+			continue
+		}
+		if !inCode {
+			lines[i] = headingLevelRe.ReplaceAllString(line, "##$1$2")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Notas completas de todas as versões, em texto, para a janela com rolagem
+func changelogText(releases []GithubRelease) string {
+	var b strings.Builder
+	for _, r := range releases {
+		fmt.Fprintf(&b, "# %s — %s\n\n", strings.TrimPrefix(r.TagName, "v"), formatDate(r.PublishedAt))
+		body := strings.TrimSpace(r.Body)
+		if body == "" {
+			body = tr("Nenhuma descrição fornecida.")
+		}
+		b.WriteString(demoteHeadings(body) + "\n\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func showChangelog(text string) {
+	cmd := exec.Command("zenity", "--text-info",
+		"--title="+trf("Novidades do %s", AppPrettyName),
+		"--width=700", "--height=550")
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Run()
+}
+
+// Novidades de todas as versões entre a instalada e a mais nova: o resumo em
+// Pango para o diálogo e o texto completo ("" se não houver lista de versões).
+// Sem a versão instalada, ou se a lista de releases falhar, mostra só a mais
+// nova.
+func updateNotes(ctx context.Context, latest *GithubRelease, installed string) (notes, full string) {
+	if installed == "" {
+		return formatReleaseNotes(latest), ""
+	}
+	src, err := releaseSource()
+	if err != nil {
+		return formatReleaseNotes(latest), ""
+	}
+	releases, err := src.ReleasesSince(ctx, installed)
+	if err != nil {
+		slog.Warn("falha ao listar as releases", "erro", err)
+		return formatReleaseNotes(latest), ""
+	}
+	between := releasesBetween(releases, installed, strings.TrimPrefix(latest.TagName, "v"))
+	if len(between) == 0 {
+		return formatReleaseNotes(latest), ""
+	}
+	return formatChangelog(between, src.ReleasesPage()), changelogText(between)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// --- SUBCOMANDOS ---
+
+func usage() {
+	fmt.Fprintln(os.Stderr, tr("Uso: tac-installer [--verbose] [comando]"))
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, tr("Sem comando, abre o instalador gráfico."))
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, tr("Comandos:"))
+	fmt.Fprintln(os.Stderr, "  permissions [list|add <pasta>|reset]   "+tr("Gerencia as pastas liberadas para o Flatpak"))
+	fmt.Fprintln(os.Stderr, "  report [arquivo.md|arquivo.tar.gz]     "+tr("Gera um relatório de diagnóstico para bugs"))
+	fmt.Fprintln(os.Stderr, "  history                                "+tr("Mostra o histórico de instalações"))
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, tr("Opções:"))
+	fmt.Fprintln(os.Stderr, "  -v, --verbose   "+trf("Repete no terminal o log gravado em %s", getLogFile()))
+}
+
+// Separa as opções globais (aceitas em qualquer posição) dos argumentos
+func parseGlobalFlags(args []string) (rest []string, verbose bool) {
+	for _, a := range args {
+		switch a {
+		case "-v", "--verbose":
+			verbose = true
+		default:
+			rest = append(rest, a)
+		}
+	}
+	return rest, verbose
+}
+
+// Executa o subcomando pedido na linha de comando. Retorna false se não houver
+// subcomando e o instalador gráfico deve seguir normalmente.
+func runSubcommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "permissions":
+		exit(runPermissionsCommand(args[1:]))
+	case "history":
+		exit(runHistoryCommand(args[1:]))
+	case "report":
+		exit(runReportCommand(args[1:]))
+	case "help", "-h", "--help":
+		usage()
+		exit(0)
+	default:
+		fmt.Fprintf(os.Stderr, "%s\n\n", trf("Comando desconhecido: %s", args[0]))
+		usage()
+		exit(2)
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// --- CONFIGURAÇÃO DO USUÁRIO ---
+
+// Lida de $XDG_CONFIG_HOME/tac-installer/config.json; o instalador nunca grava
+// nesse arquivo
+type InstallerConfig struct {
+	// Token do GitHub para não esbarrar no limite de consultas sem autenticação
+	GithubToken string `json:"github_token,omitempty"`
+	// De onde buscar as releases; vazio usa o GitHub
+	Source SourceConfig `json:"source"`
+}
+
+// type: github, github-enterprise, gitea, forgejo, gitlab ou static.
+// url é o endereço do servidor (ou do índice JSON, para static) e repo o
+// dono/repositório (ou o caminho do projeto no GitLab).
+type SourceConfig struct {
+	Type  string `json:"type,omitempty"`
+	Url   string `json:"url,omitempty"`
+	Repo  string `json:"repo,omitempty"`
+	Token string `json:"token,omitempty"`
+}
+
+func getConfigDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "tac-installer")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "tac-installer-config")
+	}
+	return filepath.Join(home, ".config", "tac-installer")
+}
+
+func getConfigFile() string {
+	return filepath.Join(getConfigDir(), "config.json")
+}
+
+func loadConfig() InstallerConfig {
+	var cfg InstallerConfig
+	data, err := os.ReadFile(getConfigFile())
+	if err != nil {
+		return cfg
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		slog.Warn("arquivo de configuração inválido", "arquivo", getConfigFile(), "erro", err)
+	}
+	return cfg
+}
+
+// GITHUB_TOKEN tem prioridade sobre o token do config.json
+func githubToken() string {
+	if t := strings.TrimSpace(os.Getenv("GITHUB_TOKEN")); t != "" {
+		return t
+	}
+	return strings.TrimSpace(loadConfig().GithubToken)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// --- FLATPAK ---
+
+const (
+	FlathubRemote  = "flathub"
+	FlathubRepoUrl = "https://dl.flathub.org/repo/flathub.flatpakrepo"
+
+	// Remote criado a partir de um .flatpakrepo publicado na release
+	CustomRemote = AppName + "-origin"
+
+	OriginFlathub    = "flathub"
+	OriginFlatpakref = "flatpakref"
+	OriginBundle     = "bundle"
+
+	ScopeUser   = "user"
+	ScopeSystem = "system"
+)
+
+func flatpakInstalled() bool {
+	return detectFlatpakScope() != ""
+}
+
+// Descobre em qual instalação (usuário ou sistema) o app está
+func detectFlatpakScope() string {
+	if _, err := exec.LookPath("flatpak"); err != nil {
+		return ""
+	}
+	for _, scope := range []string{ScopeUser, ScopeSystem} {
+		if runLogged(exec.Command("flatpak", "info", scopeFlag(scope), FlatpakID)) == nil {
+			return scope
+		}
+	}
+	return ""
+}
+
+func scopeFlag(scope string) string {
+	if scope == ScopeSystem {
+		return "--system"
+	}
+	return "--user"
+}
+
+// Escopo a usar: o da instalação existente ou o escolhido pelo usuário
+func chooseFlatpakScope() string {
+	if scope := detectFlatpakScope(); scope != "" {
+		return scope
+	}
+
+	msg := tr("<b>Para quem o Flatpak deve ser instalado?</b>\n\n" +
+		"<b>• Usuário:</b> Apenas para você. Não pede senha.\n" +
+		"<b>• Sistema:</b> Para todos os usuários. Pede a senha de administrador (polkit).")
+
+	switch zenityTripleChoice(msg, tr("Escopo do Flatpak"), tr("Usuário"), tr("Sistema"), tr("Cancelar")) {
+	case "ok":
+		return ScopeUser
+	case "extra":
+		return ScopeSystem
+	}
+	return ""
+}
+
+func runFlatpak() error {
+	return startLogged(exec.Command("flatpak", "run", scopeFlag(detectFlatpakScope()), FlatpakID))
+}
+
+func uninstallFlatpak() bool {
+	scope := detectFlatpakScope()
+	if scope == "" {
+		return false
+	}
+	return runLogged(exec.Command("flatpak", "uninstall", scopeFlag(scope), "-y", FlatpakID)) == nil
+}
+
+// Lê um campo ("Version:", "Origin:"...) da saída de flatpak info/remote-info
+func flatpakField(out, field string) string {
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, field+":") {
+			return strings.TrimSpace(strings.TrimPrefix(line, field+":"))
+		}
+	}
+	return ""
+}
+
+func flatpakInstalledVersion(scope string) string {
+	out, err := outputLogged(exec.Command("flatpak", "info", scopeFlag(scope), FlatpakID))
+	if err != nil {
+		return ""
+	}
+	return flatpakField(string(out), "Version")
+}
+
+func flatpakRemoteVersion(scope, remote string) (string, bool) {
+	out, err := outputLogged(exec.Command("flatpak", "remote-info", scopeFlag(scope), remote, FlatpakID))
+	if err != nil {
+		return "", false
+	}
+	return flatpakField(string(out), "Version"), true
+}
+
+func installFlatpak(ctx context.Context, release *GithubRelease, version string) bool {
+	if _, err := exec.LookPath("flatpak"); err != nil {
+		zenityError(tr("O comando 'flatpak' não foi encontrado. Por favor, instale o suporte a Flatpak na sua distribuição para continuar."))
+		return false
+	}
+
+	scope := chooseFlatpakScope()
+	if scope == "" {
+		return false
+	}
+
+	// --- A MÁGICA ENTRA AQUI ---
+	// Garante que o repositório do Flathub exista no escopo escolhido antes de instalar
+	// Assim ele sabe de onde baixar o org.gnome.Platform automaticamente
+	runLogged(exec.Command("flatpak", "remote-add", scopeFlag(scope), "--if-not-exists", FlathubRemote, FlathubRepoUrl))
+
+	origin, installArgs, target, cleanup, err := resolveFlatpakOrigin(ctx, release, version, scope)
+	if err != nil {
+		showErrorOrCancelled("", err)
+		return false
+	}
+	defer cleanup()
+
+	if !preinstallRuntime(ctx, origin, target, scope) {
+		return false
+	}
+
+	if err := installPackage(ctx, installArgs, false); err != nil {
+		if isCancelled(err) {
+			showCancelled()
+		} else {
+			zenityError(tr("Falha na instalação."))
+		}
+		return false
+	}
+
+	// A versão do remote pode diferir da release do GitHub
+	if v := flatpakInstalledVersion(scope); v != "" {
+		version = v
+	}
+	st := loadState()
+	st.FlatpakOrigin = origin
+	st.FlatpakScope = scope
+	storeState(st)
+	recordInstall(FormatFlatpak, version)
+	reapplyFilesystemOverrides()
+	return true
+}
+
+// Escolhe de onde instalar, em ordem de preferência:
+//  1. Flathub, se o app estiver publicado lá com a versão da release;
+//  2. .flatpakref ou .flatpakrepo publicados na release (recebem atualizações);
+//  3. o bundle .flatpak baixado, sem atualizações automáticas.
+//
+// target é o que será instalado (ID do app, URL do .flatpakref ou o bundle)
+// e já está incluído em args.
+func resolveFlatpakOrigin(ctx context.Context, release *GithubRelease, version, scope string) (origin string, args []string, target string, cleanup func(), err error) {
+	cleanup = func() {}
+	install := []string{"flatpak", "install", scopeFlag(scope), "-y"}
+	fromRemote := func(remote string) []string {
+		return append(install, "--noninteractive", "--or-update", remote, FlatpakID)
+	}
+
+	if remoteVersion, ok := flatpakRemoteVersion(scope, FlathubRemote); ok {
+		if remoteVersion == "" || compareVersions(remoteVersion, version) >= 0 {
+			return OriginFlathub, fromRemote(FlathubRemote), FlatpakID, cleanup, nil
+		}
+	}
+
+	if _, url, err := findAssetUrl(release, ".flatpakref"); err == nil {
+		return OriginFlatpakref, append(install, "--noninteractive", "--or-update", "--from", url), url, cleanup, nil
+	}
+
+	if _, url, err := findAssetUrl(release, ".flatpakrepo"); err == nil {
+		addErr := runLogged(exec.Command("flatpak", "remote-add", scopeFlag(scope), "--if-not-exists", CustomRemote, url))
+		if addErr == nil {
+			if _, ok := flatpakRemoteVersion(scope, CustomRemote); ok {
+				return CustomRemote, fromRemote(CustomRemote), FlatpakID, cleanup, nil
+			}
+		}
+	}
+
+	fileName, url, err := findAssetUrl(release, ".flatpak")
+	if err != nil {
+		return "", nil, "", cleanup, err
+	}
+	tmp, err := tempPath(fileName)
+	if err != nil {
+		return "", nil, "", cleanup, err
+	}
+	if err := downloadFile(ctx, url, tmp); err != nil {
+		os.Remove(tmp)
+		return "", nil, "", cleanup, fmt.Errorf(tr("Erro no download:\n%w"), err)
+	}
+	if err := verifyPrivate(tmp); err != nil {
+		os.Remove(tmp)
+		return "", nil, "", cleanup, err
+	}
+	cleanup = func() { os.Remove(tmp) }
+	return OriginBundle, append(install, tmp), tmp, cleanup, nil
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- RUNTIME DO FLATPAK ---
+
+var (
+	runtimeRe  = regexp.MustCompile(`(?m)^runtime=(\S+)`)
+	progressRe = regexp.MustCompile(`(\d{1,3})%`)
+)
+
+// O bundle guarda o metadata do app em texto puro no cabeçalho GVariant,
+// antes do conteúdo compactado
+func bundleRuntime(bundle string) string {
+	f, err := os.Open(bundle)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	head := make([]byte, 1<<20)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+
+	idx := bytes.Index(head, []byte("[Application]"))
+	if idx == -1 {
+		return ""
+	}
+	m := runtimeRe.FindSubmatch(head[idx:])
+	if m == nil {
+		return ""
+	}
+	return string(m[1])
+}
+
+func remoteRuntime(scope, remote string) string {
+	out, err := outputLogged(exec.Command("flatpak", "remote-info", scopeFlag(scope), "--show-metadata", remote, FlatpakID))
+	if err != nil {
+		return ""
+	}
+	m := runtimeRe.FindSubmatch(out)
+	if m == nil {
+		return ""
+	}
+	return string(m[1])
+}
+
+// Campos do grupo [Flatpak Ref] de um .flatpakref (formato .desktop)
+func flatpakrefFields(ctx context.Context, url string) map[string]string {
+	fields := map[string]string{}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fields
+	}
+	client := &http.Client{Timeout: 10 * time.Second, CheckRedirect: httpsOnly}
+	resp, err := client.Do(req)
+	if err != nil {
+		slog.Warn("falha ao ler o .flatpakref", "url", url, "erro", err)
+		return fields
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fields
+	}
+
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, 64<<10))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok {
+			fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return fields
+}
+
+// Nome de remote aceito pelo flatpak (e que não vira uma opção)
+var remoteNameRe = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*$`)
+
+// O .flatpakref não informa o runtime, só o repositório do app. Antes da
+// primeira instalação o remote ainda não existe, então ele é criado aqui com o
+// mesmo nome que o flatpak install --from usaria (SuggestRemoteName ou
+// "<Name>-origin") e a chave GPG do arquivo; o install depois reaproveita o
+// remote com o mesmo endereço. O runtime vem do RuntimeRepo, se houver, do
+// remote do app ou do Flathub.
+func flatpakrefRuntime(ctx context.Context, url, scope string) (string, []string) {
+	fields := flatpakrefFields(ctx, url)
+	remote, err := addFlatpakrefRemote(scope, fields)
+	if err != nil {
+		slog.Warn("remote do .flatpakref não adicionado", "url", url, "erro", err)
+		return "", nil
+	}
+
+	var remotes []string
+	if repo := fields["RuntimeRepo"]; repo != "" {
+		if r, err := addRuntimeRepo(scope, repo); err == nil {
+			remotes = append(remotes, r)
+		} else {
+			slog.Warn("RuntimeRepo do .flatpakref não adicionado", "url", repo, "erro", err)
+		}
+	}
+	return remoteRuntime(scope, remote), append(remotes, remote, FlathubRemote)
+}
+
+func addFlatpakrefRemote(scope string, fields map[string]string) (string, error) {
+	name := fields["SuggestRemoteName"]
+	if name == "" && fields["Name"] != "" {
+		name = fields["Name"] + "-origin"
+	}
+	repo := fields["Url"]
+	if !remoteNameRe.MatchString(name) || !strings.HasPrefix(repo, "https://") {
+		return "", fmt.Errorf("remote %q ou endereço %q inválido", name, repo)
+	}
+	// Sem a chave o remote ficaria sem verificação de assinatura
+	key, err := base64.StdEncoding.DecodeString(fields["GPGKey"])
+	if err != nil || len(key) == 0 {
+		return "", errors.New("o .flatpakref não tem GPGKey")
+	}
+	keyFile, err := tempPath(name + ".gpg")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(keyFile, key, 0600); err != nil {
+		return "", err
+	}
+	defer os.Remove(keyFile)
+
+	err = runLogged(exec.Command("flatpak", "remote-add", scopeFlag(scope), "--if-not-exists", "--gpg-import="+keyFile, name, repo))
+	return name, err
+}
+
+// Adiciona o .flatpakrepo indicado em RuntimeRepo, com o nome do arquivo
+func addRuntimeRepo(scope, repo string) (string, error) {
+	if repo == FlathubRepoUrl {
+		return FlathubRemote, nil
+	}
+	name := strings.TrimSuffix(path.Base(repo), ".flatpakrepo")
+	if !remoteNameRe.MatchString(name) || !strings.HasPrefix(repo, "https://") {
+		return "", fmt.Errorf("RuntimeRepo inválido: %q", repo)
+	}
+	return name, runLogged(exec.Command("flatpak", "remote-add", scopeFlag(scope), "--if-not-exists", "--from", name, repo))
+}
+
+// Runtime exigido pelo app, no formato "org.gnome.Platform/x86_64/47", e os
+// remotes onde procurá-lo, em ordem de preferência
+func requiredRuntime(ctx context.Context, origin, target, scope string) (string, []string) {
+	switch origin {
+	case OriginBundle:
+		return bundleRuntime(target), []string{FlathubRemote}
+	case OriginFlatpakref:
+		return flatpakrefRuntime(ctx, target, scope)
+	default:
+		return remoteRuntime(scope, origin), []string{origin, FlathubRemote}
+	}
+}
+
+// Um runtime do sistema também serve para apps do usuário
+func runtimeInstalled(ref string) bool {
+	return runLogged(exec.Command("flatpak", "info", ref)) == nil
+}
+
+// Primeiro remote que tem o runtime, com o tamanho do download
+func runtimeRemote(scope, ref string, remotes []string) (remote, size string) {
+	for _, r := range remotes {
+		out, err := outputLogged(exec.Command("flatpak", "remote-info", scopeFlag(scope), r, ref))
+		if err == nil {
+			return r, flatpakField(string(out), "Download")
+		}
+	}
+	return "", ""
+}
+
+// Instala o runtime antes do app para que o download apareça com progresso real
+func preinstallRuntime(ctx context.Context, origin, target, scope string) bool {
+	ref, remotes := requiredRuntime(ctx, origin, target, scope)
+	if ref == "" || runtimeInstalled(ref) {
+		return true
+	}
+	// Sem um remote conhecido, o próprio flatpak install baixa o runtime
+	remote, size := runtimeRemote(scope, ref, remotes)
+	if remote == "" {
+		return true
+	}
+
+	if size == "" {
+		size = tr("desconhecido")
+	}
+	msg := trf(
+		"O %s precisa do runtime <b>%s</b>, que ainda não está instalado.\n\n"+
+			"<b>Tamanho do download</b>: %s\n\n"+
+			"Ele será instalado agora a partir do remote <b>%s</b>. Deseja continuar?",
+		AppPrettyName, escapeMarkup(ref), escapeMarkup(size), escapeMarkup(remote))
+	if !zenityQuestion(msg) {
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	update, done := startProgress(tr("Instalando runtime..."), trf("Baixando %s...", ref), cancel)
+	out, err := runWithProgress(
+		exec.CommandContext(ctx, "flatpak", "install", scopeFlag(scope), "--noninteractive", "-y", remote, ref),
+		update,
+	)
+	done()
+
+	if ctx.Err() != nil {
+		showCancelled()
+		return false
+	}
+	if err != nil {
+		showCommandError(tr("Erro ao instalar o runtime"), out, err)
+		return false
+	}
+	return true
+}
+
+// Executa o comando repassando cada porcentagem encontrada na saída.
+// O flatpak redesenha a linha de progresso com \r, então ambos separam linhas.
+// Comandos criados com exec.CommandContext são encerrados junto com os filhos
+// quando o contexto é cancelado
+func runWithProgress(cmd *exec.Cmd, update func(pct int, line string)) ([]byte, error) {
+	defer trackCommand(false)()
+	if cmd.Cancel != nil {
+		killGroupOnCancel(cmd)
+	}
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	var log bytes.Buffer
+	scanDone := make(chan struct{})
+	go func() {
+		defer close(scanDone)
+		scanner := bufio.NewScanner(pr)
+		scanner.Split(scanLinesOrCR)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			log.WriteString(line + "\n")
+			pct := -1
+			if m := progressRe.FindStringSubmatch(line); m != nil {
+				pct, _ = strconv.Atoi(m[1])
+			}
+			update(pct, line)
+		}
+		io.Copy(io.Discard, pr)
+	}()
+
+	err := runLogged(cmd)
+	pw.Close()
+	<-scanDone
+	return log.Bytes(), err
+}
+
+func scanLinesOrCR(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- API DO GITHUB ---
+
+// Limite de consultas da API atingido (403/429). Sem token, o GitHub aceita
+// 60 consultas por hora por IP, que se esgotam rápido atrás de um NAT.
+type rateLimitError struct {
+	Service string
+	Reset   time.Time // zero se o servidor não informou
+	Hint    string
+}
+
+func (e *rateLimitError) Error() string {
+	msg := trf("limite de consultas à API do %s atingido", e.Service)
+	if !e.Reset.IsZero() {
+		wait := time.Until(e.Reset).Round(time.Minute)
+		if wait < time.Minute {
+			wait = time.Minute
+		}
+		msg += "; " + trf("tente novamente às %s (em %d min)", e.Reset.Local().Format("15:04"), int(wait.Minutes()))
+	}
+	if e.Hint != "" {
+		msg += ".\n" + e.Hint
+	}
+	return msg
+}
+
+// 403 só é limite de consultas quando não restam consultas ou há Retry-After
+func rateLimitFromResponse(status int, h http.Header) *rateLimitError {
+	if status != http.StatusForbidden && status != http.StatusTooManyRequests {
+		return nil
+	}
+	e := &rateLimitError{}
+	if secs, err := strconv.Atoi(h.Get("Retry-After")); err == nil {
+		e.Reset = time.Now().Add(time.Duration(secs) * time.Second)
+	} else if unix, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		e.Reset = time.Unix(unix, 0)
+	}
+	if status == http.StatusTooManyRequests || h.Get("X-RateLimit-Remaining") == "0" || h.Get("Retry-After") != "" {
+		return e
+	}
+	return nil
+}
+
+// github.com ou GitHub Enterprise (api = <url>/api/v3)
+type githubSource struct {
+	api   string
+	web   string
+	repo  string
+	token string
+}
+
+// O token do github.com (GITHUB_TOKEN/github_token) nunca vai para um
+// servidor Enterprise; lá só o "source.token"
+func newGithubSource(baseUrl, repo, token string) *githubSource {
+	if baseUrl == "" {
+		if token == "" {
+			token = githubToken()
+		}
+		return &githubSource{api: "https://api.github.com", web: "https://github.com", repo: repo, token: token}
+	}
+	baseUrl = strings.TrimRight(baseUrl, "/")
+	return &githubSource{api: baseUrl + "/api/v3", web: baseUrl, repo: repo, token: token}
+}
+
+func (s *githubSource) get(ctx context.Context, path string, v any) error {
+	h := http.Header{}
+	h.Set("User-Agent", "Go-Installer-Zenity")
+	h.Set("Accept", "application/vnd.github+json")
+	if s.token != "" {
+		h.Set("Authorization", "Bearer "+s.token)
+	}
+
+	hint := ""
+	if s.token == "" && s.api == "https://api.github.com" {
+		hint = trf("Para evitar o limite, defina a variável GITHUB_TOKEN ou \"github_token\" em %s.", getConfigFile())
+	}
+	return apiGet(ctx, "GitHub", s.api+"/repos/"+s.repo+path, h, hint, v)
+}
+
+func (s *githubSource) Latest(ctx context.Context) (*GithubRelease, error) {
+	var release GithubRelease
+	if err := s.get(ctx, "/releases/latest", &release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+func (s *githubSource) ReleasesSince(ctx context.Context, since string) ([]GithubRelease, error) {
+	return pagedReleases(since, func(page int) ([]GithubRelease, error) {
+		var releases []GithubRelease
+		err := s.get(ctx, "/releases?per_page="+strconv.Itoa(releasesPerPage)+"&page="+strconv.Itoa(page), &releases)
+		return releases, err
+	})
+}
+
+func (s *githubSource) ReleasesPage() string {
+	return s.web + "/" + s.repo + "/releases"
+}
@@ -0,0 +1,3 @@
+module github.com/jyahyah/tac-installer
+
+go 1.21
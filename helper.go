@@ -0,0 +1,333 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// --- PROCESSO AUXILIAR PRIVILEGIADO ---
+
+// Em vez de chamar o pkexec (ou sudo) para cada comando, o instalador inicia
+// uma única cópia de si mesmo como root, que recebe os comandos por stdin e
+// devolve a saída por stdout. Assim a senha é pedida uma vez só por execução
+// (no openSUSE eram duas: dependências e depois o pacote).
+//
+// O auxiliar só executa comandos de uma lista fechada (instalar/remover o
+// pacote do app com o gerenciador do sistema, dependências do SUSE e do
+// AUR) e só aceita arquivos que estejam no diretório temporário privado do instalador.
+
+// Subcomando oculto; precisa bater com o exec.argv1 da política do polkit em data/
+const HelperCommand = "privileged-helper"
+
+type helperRequest struct {
+	Argv []string `json:"argv"`
+}
+
+type helperMessage struct {
+	Ready  bool   `json:"ready,omitempty"`
+	Output string `json:"output,omitempty"`
+	Done   bool   `json:"done,omitempty"`
+	Code   int    `json:"code,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Argumentos de instalação das dependências do openSUSE
+func suseDepsArgs() []string {
+	return append([]string{"zypper", "--non-interactive", "install", "-y"}, strings.Fields(SuseDeps)...)
+}
+
+// --- LADO DO INSTALADOR ---
+
+type helperClient struct {
+	mu  sync.Mutex
+	cmd *exec.Cmd
+	enc *json.Encoder
+	dec *json.Decoder
+}
+
+var (
+	helperMu     sync.Mutex
+	helperActive *helperClient
+	helperErr    error
+)
+
+// Devolve o auxiliar da execução, iniciando-o (e pedindo a senha) na primeira
+// chamada. Retorna nil sem erro quando o mecanismo não permite um auxiliar e
+// os comandos devem ser executados um a um.
+func getHelper(m *privilegeMethod) (*helperClient, error) {
+	helperMu.Lock()
+	defer helperMu.Unlock()
+	if helperActive != nil || helperErr != nil {
+		return helperActive, helperErr
+	}
+
+	// O agente de texto do pkexec/run0 precisa do stdin, que aqui é o canal
+	// de comandos; o sudo lê a senha direto do /dev/tty
+	if m.Name == "root" || (m.UseTTY && m.Name != "sudo") {
+		return nil, nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, nil
+	}
+
+	helperActive, helperErr = startHelper(m, exe)
+	return helperActive, helperErr
+}
+
+func startHelper(m *privilegeMethod, exe string) (*helperClient, error) {
+	argv := m.argv(exe, HelperCommand)
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = append(os.Environ(), m.Env...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	// Se o processo do instalador morrer, o stdin do auxiliar fecha e ele
+	// termina sozinho
+	h := &helperClient{cmd: cmd, enc: json.NewEncoder(stdin), dec: json.NewDecoder(stdout)}
+
+	var msg helperMessage
+	if err := h.dec.Decode(&msg); err != nil || !msg.Ready {
+		stdin.Close()
+		waitErr := cmd.Wait()
+		if exitErr, ok := waitErr.(*exec.ExitError); ok && (exitErr.ExitCode() == 126 || exitErr.ExitCode() == 127) {
+			return nil, errors.New(tr("autenticação cancelada ou recusada"))
+		}
+		if msg.Error != "" {
+			return nil, errors.New(msg.Error)
+		}
+		return nil, fmt.Errorf(tr("o processo auxiliar privilegiado não iniciou: %v"), waitErr)
+	}
+	return h, nil
+}
+
+// Envia um comando ao auxiliar e repassa a saída para out
+func (h *helperClient) Run(out io.Writer, name string, args ...string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.enc.Encode(helperRequest{Argv: append([]string{name}, args...)}); err != nil {
+		return fmt.Errorf(tr("o processo auxiliar privilegiado foi encerrado: %v"), err)
+	}
+	for {
+		var msg helperMessage
+		if err := h.dec.Decode(&msg); err != nil {
+			return fmt.Errorf(tr("o processo auxiliar privilegiado foi encerrado: %v"), err)
+		}
+		if msg.Output != "" {
+			io.WriteString(out, msg.Output)
+		}
+		if !msg.Done {
+			continue
+		}
+		if msg.Error != "" {
+			return errors.New(msg.Error)
+		}
+		if msg.Code != 0 {
+			return fmt.Errorf(tr("%s terminou com código %d"), name, msg.Code)
+		}
+		return nil
+	}
+}
+
+// --- LADO DO AUXILIAR (ROOT) ---
+
+// Grava as mensagens em stdout; a saída dos comandos chega de duas goroutines
+type helperWriter struct {
+	mu  *sync.Mutex
+	enc *json.Encoder
+}
+
+func (w helperWriter) send(msg helperMessage) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_ = w.enc.Encode(msg)
+}
+
+func (w helperWriter) Write(p []byte) (int, error) {
+	w.send(helperMessage{Output: string(p)})
+	return len(p), nil
+}
+
+func runPrivilegedHelper() int {
+	w := helperWriter{mu: &sync.Mutex{}, enc: json.NewEncoder(os.Stdout)}
+	if os.Geteuid() != 0 {
+		w.send(helperMessage{Error: tr("o processo auxiliar precisa ser executado como root")})
+		return 1
+	}
+
+	// Um Ctrl+C no terminal do instalador não pode interromper o dpkg/rpm; o
+	// auxiliar termina quando o instalador fechar o stdin
+	signal.Ignore(syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	// pkexec e run0 limpam o ambiente
+	os.Setenv("PATH", "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin")
+	w.send(helperMessage{Ready: true})
+
+	dec := json.NewDecoder(os.Stdin)
+	for {
+		var req helperRequest
+		if err := dec.Decode(&req); err != nil {
+			// stdin fechado: o instalador terminou
+			return 0
+		}
+		if err := allowedRootCommand(req.Argv); err != nil {
+			w.send(helperMessage{Done: true, Error: trf("comando recusado pelo auxiliar privilegiado: %v", err)})
+			continue
+		}
+
+		cmd := exec.Command(req.Argv[0], req.Argv[1:]...)
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		cmd.Stdout = w
+		cmd.Stderr = w
+		err := cmd.Run()
+
+		msg := helperMessage{Done: true}
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			msg.Code = exitErr.ExitCode()
+		} else if err != nil {
+			msg.Error = err.Error()
+		}
+		w.send(msg)
+	}
+}
+
+// Lista fechada de comandos aceitos pelo auxiliar
+func allowedRootCommand(argv []string) error {
+	if len(argv) == 0 {
+		return errors.New(tr("comando vazio"))
+	}
+	if equalArgs(argv, suseDepsArgs()) || equalArgs(argv, aurToolsArgs) {
+		return nil
+	}
+	if len(argv) > len(aurDepsArgs) && equalArgs(argv[:len(aurDepsArgs)], aurDepsArgs) {
+		for _, name := range argv[len(aurDepsArgs):] {
+			if !pacmanNameRe.MatchString(name) {
+				return fmt.Errorf(tr("nome de pacote inválido: %s"), name)
+			}
+		}
+		return nil
+	}
+
+	for _, b := range nativeBackends {
+		if !b.NeedsRoot {
+			continue
+		}
+		if equalArgs(argv, b.RemoveArgs) {
+			return nil
+		}
+		for _, prefix := range [][]string{b.InstallArgs, b.RollbackArgs} {
+			if len(prefix) == 0 || len(argv) <= len(prefix) || !equalArgs(argv[:len(prefix)], prefix) {
+				continue
+			}
+
+			rest := argv[len(prefix):]
+			if b.Manager == "xbps" {
+				if len(rest) == 3 && rest[0] == "--repository" && rest[2] == AppName {
+					return checkHelperPath(rest[1], true, "")
+				}
+				continue
+			}
+			if len(rest) == 1 {
+				return checkHelperPath(rest[0], false, b.Suffix)
+			}
+		}
+	}
+	return fmt.Errorf(tr("%s não está entre os comandos permitidos"), strings.Join(argv, " "))
+}
+
+// Nome de pacote do pacman (nunca uma opção como --config)
+var pacmanNameRe = regexp.MustCompile(`^[a-z0-9@_+][a-z0-9@._+-]*$`)
+
+func equalArgs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Confere que o caminho está dentro de um diretório tac-installer-* privado,
+// sem links simbólicos e pertencente ao usuário que iniciou o auxiliar
+func checkHelperPath(path string, isDir bool, suffix string) error {
+	if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+		return fmt.Errorf(tr("caminho inválido: %s"), path)
+	}
+	if suffix != "" && !strings.HasSuffix(path, suffix) {
+		return fmt.Errorf(tr("%s não é um pacote %s"), path, suffix)
+	}
+
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if isDir != fi.IsDir() || (!isDir && !fi.Mode().IsRegular()) {
+		return fmt.Errorf(tr("tipo de arquivo inesperado: %s"), path)
+	}
+
+	owner := fileOwner(fi)
+	if uid, ok := helperCallerUid(); ok && uid != owner {
+		return fmt.Errorf(tr("%s não pertence ao usuário que iniciou o instalador"), path)
+	}
+
+	for p := path; p != filepath.Dir(p); p = filepath.Dir(p) {
+		fi, err := os.Lstat(p)
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf(tr("%s é um link simbólico"), p)
+		}
+		if fileOwner(fi) != owner {
+			return fmt.Errorf(tr("%s pertence a outro usuário"), p)
+		}
+		if p != path && fi.Mode().Perm()&0022 != 0 {
+			return fmt.Errorf(tr("%s tem permissão de escrita para outros usuários"), p)
+		}
+		if strings.HasPrefix(filepath.Base(p), "tac-installer-") && fi.IsDir() {
+			if fi.Mode().Perm() != 0700 {
+				return fmt.Errorf(tr("permissões inseguras em %s: %v"), p, fi.Mode().Perm())
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf(tr("%s está fora do diretório temporário do instalador"), path)
+}
+
+// Usuário que pediu a elevação, informado pelo pkexec ou pelo sudo
+func helperCallerUid() (int, bool) {
+	for _, env := range []string{"PKEXEC_UID", "SUDO_UID"} {
+		if v := os.Getenv(env); v != "" {
+			if uid, err := strconv.Atoi(v); err == nil {
+				return uid, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// --- HISTÓRICO DE OPERAÇÕES ---
+
+const (
+	ActionInstall   = "instalação"
+	ActionUpdate    = "atualização"
+	ActionUninstall = "remoção"
+	ActionRollback  = "reversão"
+
+	ResultOk        = "sucesso"
+	ResultFailed    = "falha"
+	ResultCancelled = "cancelado"
+)
+
+type HistoryEntry struct {
+	Time        string `json:"time"`
+	Action      string `json:"action"`
+	Format      string `json:"format,omitempty"`
+	FromVersion string `json:"from_version,omitempty"`
+	ToVersion   string `json:"to_version,omitempty"`
+	Result      string `json:"result"`
+	Asset       string `json:"asset,omitempty"`
+	Checksum    string `json:"sha256,omitempty"`
+}
+
+// Último arquivo baixado nesta execução, registrado junto com a operação
+var lastDownload struct {
+	Name     string
+	Checksum string
+}
+
+func getHistoryFile() string {
+	return filepath.Join(getStateDir(), "history.jsonl")
+}
+
+// Uma linha JSON por operação; o arquivo só recebe acréscimos
+func appendHistory(e HistoryEntry) {
+	e.Time = time.Now().Format(time.RFC3339)
+	slog.Info("histórico", "ação", e.Action, "formato", e.Format, "de", e.FromVersion, "para", e.ToVersion, "resultado", e.Result, "sha256", e.Checksum)
+
+	data, err := json.Marshal(e)
+	if err == nil {
+		err = os.MkdirAll(getStateDir(), 0755)
+	}
+	var f *os.File
+	if err == nil {
+		f, err = os.OpenFile(getHistoryFile(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	}
+	if err == nil {
+		_, err = f.Write(append(data, '\n'))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		slog.Error("falha ao gravar o histórico", "arquivo", getHistoryFile(), "erro", err)
+	}
+}
+
+// Registra o resultado de uma instalação/atualização com o último download
+func recordHistory(action, format, from, to string, err error) {
+	result := ResultOk
+	if isCancelled(err) {
+		result = ResultCancelled
+	} else if err != nil {
+		result = ResultFailed
+	}
+	appendHistory(HistoryEntry{
+		Action:      action,
+		Format:      format,
+		FromVersion: from,
+		ToVersion:   to,
+		Result:      result,
+		Asset:       lastDownload.Name,
+		Checksum:    lastDownload.Checksum,
+	})
+}
+
+func loadHistory() []HistoryEntry {
+	f, err := os.Open(getHistoryFile())
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e HistoryEntry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Versão registrada antes da operação atual
+func previousVersion() string {
+	if v := loadState().Version; v != "" {
+		return v
+	}
+	v, _ := getInstalledVersion()
+	return v
+}
+
+func fileSha256(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// --- PACOTES GUARDADOS PARA REVERSÃO ---
+
+func getCacheDir() string {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "tac-installer")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "tac-installer-cache")
+	}
+	return filepath.Join(home, ".cache", "tac-installer")
+}
+
+func getPackageCacheDir() string {
+	return filepath.Join(getCacheDir(), "packages")
+}
+
+// Copia o pacote nativo para o cache antes da instalação
+func cachePackage(file string) (string, error) {
+	dest := filepath.Join(getPackageCacheDir(), filepath.Base(file))
+	if err := copyFile(file, dest, 0644); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// Mantém no cache apenas o pacote instalado agora e o da versão anterior
+func prunePackageCache(keep ...string) {
+	files, _ := filepath.Glob(filepath.Join(getPackageCacheDir(), "*"))
+	for _, f := range files {
+		keepIt := false
+		for _, k := range keep {
+			if k != "" && filepath.Base(f) == k {
+				keepIt = true
+			}
+		}
+		if !keepIt {
+			os.Remove(f)
+		}
+	}
+}
+
+// Depois de uma instalação nativa bem-sucedida (já registrada no histórico)
+func pruneAfterInstall(asset string) {
+	previous := ""
+	if e, _, ok := rollbackCandidate(); ok {
+		previous = e.Asset
+	}
+	prunePackageCache(asset, previous)
+}
+
+// Pacote nativo de uma versão anterior à atual que ainda está no cache
+func rollbackCandidate() (HistoryEntry, string, bool) {
+	entries := loadHistory()
+	current := ""
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Format != FormatNative || e.Result != ResultOk || e.Asset == "" {
+			continue
+		}
+		if current == "" {
+			current = e.ToVersion
+			continue
+		}
+		// Depois de uma reversão, as entradas mais novas que a versão atual
+		// seriam uma atualização, não uma reversão
+		if compareVersions(e.ToVersion, current) >= 0 {
+			continue
+		}
+		path := filepath.Join(getPackageCacheDir(), e.Asset)
+		if fileSha256(path) == e.Checksum {
+			return e, path, true
+		}
+	}
+	return HistoryEntry{}, "", false
+}
+
+// Reinstala o pacote da versão anterior guardado no cache
+func rollbackNative(ctx context.Context, distro DistroInfo) {
+	target, cached, ok := rollbackCandidate()
+	backend := detectNativeBackend(distro)
+	if !ok || backend == nil {
+		zenityError(tr("Nenhuma versão anterior disponível para reverter."))
+		return
+	}
+
+	current := previousVersion()
+	msg := trf("Voltar o <b>%s</b> para a versão anterior?\n\n<b>Versão atual</b>: %s\n<b>Versão anterior</b>: %s",
+		AppPrettyName, escapeMarkup(orNone(current)), escapeMarkup(target.ToVersion))
+	if !zenityQuestionCustomTitle(msg, tr("Reverter versão")) {
+		return
+	}
+	if backend.NeedsRoot {
+		if _, err := detectPrivilege(); err != nil {
+			zenityError(escapeMarkup(err.Error()))
+			return
+		}
+	}
+
+	// O root só recebe arquivos do diretório temporário privado
+	tmp, err := tempPath(target.Asset)
+	if err == nil {
+		err = copyFile(cached, tmp, 0644)
+	}
+	if err == nil {
+		err = verifyPrivate(tmp)
+	}
+	lastDownload.Name, lastDownload.Checksum = target.Asset, target.Checksum
+	if err != nil {
+		recordHistory(ActionRollback, FormatNative, current, target.ToVersion, err)
+		zenityError(trf("Erro ao preparar o pacote anterior:\n%s", escapeMarkup(err.Error())))
+		return
+	}
+	defer os.Remove(tmp)
+
+	installArgs, cleanup, err := backend.rollbackArgs(tmp)
+	if err != nil {
+		recordHistory(ActionRollback, FormatNative, current, target.ToVersion, err)
+		zenityError(trf("Erro ao preparar o pacote:\n%v", err))
+		return
+	}
+	defer cleanup()
+
+	err = installPackage(ctx, installArgs, backend.NeedsRoot)
+	recordHistory(ActionRollback, FormatNative, current, target.ToVersion, err)
+	switch {
+	case err == nil:
+		recordInstall(FormatNative, target.ToVersion)
+		zenityInfo(trf("O <b>%s</b> voltou para a versão %s.", AppPrettyName, escapeMarkup(target.ToVersion)))
+	case isCancelled(err):
+		showCancelled()
+	default:
+		zenityError(tr("Falha ao reverter a versão."))
+	}
+}
+
+// --- EXIBIÇÃO ---
+
+func historyRows(entries []HistoryEntry) [][]string {
+	var rows [][]string
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		sum := e.Checksum
+		if len(sum) > 12 {
+			sum = sum[:12]
+		}
+		date := e.Time
+		if t, err := time.Parse(time.RFC3339, e.Time); err == nil {
+			date = t.Local().Format("02/01/2006 15:04")
+		}
+		// Ação, formato e resultado ficam gravados em português
+		rows = append(rows, []string{date, tr(e.Action), orDash(tr(e.Format)), orDash(e.FromVersion), orDash(e.ToVersion), tr(e.Result), orDash(sum)})
+	}
+	return rows
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func historyColumns() []string {
+	return []string{tr("Data"), tr("Ação"), tr("Formato"), tr("De"), tr("Para"), tr("Resultado"), "SHA-256"}
+}
+
+// Lista o histórico com o botão de reverter quando houver versão anterior
+func handleHistory(ctx context.Context, distro DistroInfo) {
+	entries := loadHistory()
+	if len(entries) == 0 {
+		zenityInfo(tr("Nenhuma operação registrada ainda."))
+		return
+	}
+
+	args := []string{"--list", "--title=" + trf("Histórico do %s", AppPrettyName), "--width=850", "--height=450"}
+	for _, c := range historyColumns() {
+		args = append(args, "--column="+c)
+	}
+	rollbackLabel := ""
+	if target, _, ok := rollbackCandidate(); ok && installedFormat() == FormatNative {
+		rollbackLabel = trf("Voltar para a versão %s", target.ToVersion)
+		args = append(args, "--extra-button="+rollbackLabel)
+	}
+	for _, row := range historyRows(entries) {
+		args = append(args, row...)
+	}
+
+	out, _ := exec.Command("zenity", args...).Output()
+	if rollbackLabel != "" && strings.TrimSpace(string(out)) == rollbackLabel {
+		rollbackNative(ctx, distro)
+	}
+}
+
+// tac-installer history
+func runHistoryCommand(args []string) int {
+	if len(args) > 0 {
+		usage()
+		return 2
+	}
+	entries := loadHistory()
+	if len(entries) == 0 {
+		fmt.Println(tr("Nenhuma operação registrada ainda."))
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(historyColumns(), "\t"))
+	for _, row := range historyRows(entries) {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+	return 0
+}
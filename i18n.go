@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// --- TRADUÇÕES ---
+
+// As mensagens são escritas em português (pt_BR) direto no código; cada
+// po/<idioma>.po traduz o texto original (msgid) para o idioma (msgstr)
+
+//go:embed po/*.po
+var poFiles embed.FS
+
+// Idioma do código-fonte, que não precisa de catálogo
+const sourceLanguage = "pt"
+
+var (
+	catalogOnce sync.Once
+	catalog     map[string]string
+)
+
+// Idioma das mensagens conforme LANGUAGE, LC_ALL, LC_MESSAGES e LANG (nessa
+// ordem, como no gettext). Idiomas sem tradução caem para o inglês.
+func detectLanguage() string {
+	// LANGUAGE é uma lista de preferências ("es:en") e vale só se houver
+	// tradução para algum deles
+	for _, l := range strings.Split(os.Getenv("LANGUAGE"), ":") {
+		if lang := languageCode(l); lang == sourceLanguage || hasCatalog(lang) {
+			return lang
+		}
+	}
+
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := os.Getenv(env)
+		if v == "" {
+			continue
+		}
+		switch lang := languageCode(v); {
+		case lang == "c" || lang == "posix":
+			return sourceLanguage
+		case lang == sourceLanguage || hasCatalog(lang):
+			return lang
+		}
+		return "en"
+	}
+	return sourceLanguage
+}
+
+// "pt_BR.UTF-8@euro" -> "pt"
+func languageCode(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "_.@-"); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+func hasCatalog(lang string) bool {
+	if lang == "" {
+		return false
+	}
+	_, err := poFiles.Open("po/" + lang + ".po")
+	return err == nil
+}
+
+func loadCatalog() {
+	lang := detectLanguage()
+	if lang == sourceLanguage {
+		return
+	}
+	f, err := poFiles.Open("po/" + lang + ".po")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	catalog = parsePo(bufio.NewScanner(f))
+}
+
+// Lê as entradas msgid/msgstr de um arquivo .po (sem plurais nem contexto).
+// Traduções vazias ficam de fora e o texto original é usado.
+func parsePo(scanner *bufio.Scanner) map[string]string {
+	entries := map[string]string{}
+	var id, str strings.Builder
+	var field *strings.Builder
+
+	flush := func() {
+		if id.Len() > 0 && str.Len() > 0 {
+			entries[id.String()] = str.String()
+		}
+		id.Reset()
+		str.Reset()
+		field = nil
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "msgid "):
+			flush()
+			field = &id
+			line = strings.TrimPrefix(line, "msgid ")
+		case strings.HasPrefix(line, "msgstr "):
+			field = &str
+			line = strings.TrimPrefix(line, "msgstr ")
+		case !strings.HasPrefix(line, `"`):
+			// msgctxt, msgid_plural...: não usados
+			field = nil
+			continue
+		}
+		if field == nil {
+			continue
+		}
+		if s, err := strconv.Unquote(line); err == nil {
+			field.WriteString(s)
+		}
+	}
+	flush()
+	return entries
+}
+
+// Traduz uma mensagem escrita em português
+func tr(msgid string) string {
+	catalogOnce.Do(loadCatalog)
+	if s, ok := catalog[msgid]; ok {
+		return s
+	}
+	return msgid
+}
+
+// Traduz o formato e depois preenche os argumentos, como fmt.Sprintf
+func trf(format string, args ...any) string {
+	return fmt.Sprintf(tr(format), args...)
+}
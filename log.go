@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- LOG ---
+
+// Tamanho a partir do qual o log é renomeado para installer.log.1
+const maxLogSize = 1 << 20
+
+// Saída de cada comando guardada no log (apenas o final)
+const maxLoggedOutput = 16 << 10
+
+var verboseLog bool
+
+func getLogFile() string {
+	return filepath.Join(getStateDir(), "installer.log")
+}
+
+// Abre o installer.log (com data, hora e nível em cada linha) e, com
+// --verbose, repete tudo no stderr. Sem o arquivo, o log vai só para o stderr.
+func setupLogging(verbose bool) {
+	verboseLog = verbose
+
+	var writers []io.Writer
+	if f, err := openLogFile(); err == nil {
+		writers = append(writers, f)
+	} else {
+		fmt.Fprintln(os.Stderr, trf("Aviso: não foi possível abrir o log: %v", err))
+	}
+	if verbose || len(writers) == 0 {
+		writers = append(writers, os.Stderr)
+	}
+
+	handler := slog.NewTextHandler(io.MultiWriter(writers...), &slog.HandlerOptions{Level: slog.LevelDebug})
+	slog.SetDefault(slog.New(handler))
+	slog.Info("instalador iniciado", "args", os.Args[1:], "pid", os.Getpid())
+}
+
+func openLogFile() (*os.File, error) {
+	if err := os.MkdirAll(getStateDir(), 0755); err != nil {
+		return nil, err
+	}
+	path := getLogFile()
+	if fi, err := os.Stat(path); err == nil && fi.Size() > maxLogSize {
+		os.Rename(path, path+".1")
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+}
+
+// Erro sem interface gráfica disponível: vai para o log e para o terminal
+func consoleError(msg string) {
+	slog.Error(msg)
+	if !verboseLog {
+		fmt.Fprintln(os.Stderr, msg)
+	}
+}
+
+// --- COMANDOS EXTERNOS ---
+
+// Guarda os últimos bytes escritos
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > maxLoggedOutput {
+		t.buf = t.buf[len(t.buf)-maxLoggedOutput:]
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.TrimSpace(string(t.buf))
+}
+
+func teeTo(w, capture io.Writer) io.Writer {
+	if w == nil {
+		return capture
+	}
+	return io.MultiWriter(w, capture)
+}
+
+// Registra um comando já executado: argumentos, código de saída, duração e saída
+func logCommand(argv []string, err error, output string, elapsed time.Duration) {
+	code := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		code = exitErr.ExitCode()
+	} else if err != nil {
+		code = -1
+	}
+
+	attrs := []any{"args", argv, "código", code, "duração", elapsed.Round(time.Millisecond)}
+	if err != nil && code == -1 {
+		attrs = append(attrs, "erro", err.Error())
+	}
+	if output != "" {
+		attrs = append(attrs, "saída", output)
+	}
+	slog.Debug("comando", attrs...)
+}
+
+// Executa cmd como cmd.Run, registrando o comando no log
+func runLogged(cmd *exec.Cmd) error {
+	capture := &tailBuffer{}
+	if cmd.Stdout != nil && cmd.Stdout == cmd.Stderr {
+		// Mantém um único writer para não haver escrita concorrente nele
+		w := teeTo(cmd.Stdout, capture)
+		cmd.Stdout, cmd.Stderr = w, w
+	} else {
+		cmd.Stdout = teeTo(cmd.Stdout, capture)
+		cmd.Stderr = teeTo(cmd.Stderr, capture)
+	}
+
+	start := time.Now()
+	err := cmd.Run()
+	logCommand(cmd.Args, err, capture.String(), time.Since(start))
+	return err
+}
+
+// Como cmd.Output (só o stdout), registrando o comando no log
+func outputLogged(cmd *exec.Cmd) ([]byte, error) {
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := runLogged(cmd)
+	return out.Bytes(), err
+}
+
+// Como cmd.CombinedOutput, registrando o comando no log
+func combinedLogged(cmd *exec.Cmd) ([]byte, error) {
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	err := runLogged(cmd)
+	return out.Bytes(), err
+}
+
+// Como cmd.Start, para programas que continuam rodando (app, terminal)
+func startLogged(cmd *exec.Cmd) error {
+	err := cmd.Start()
+	if err != nil {
+		slog.Warn("falha ao iniciar", "args", cmd.Args, "erro", err)
+	} else {
+		slog.Debug("iniciado", "args", cmd.Args, "pid", cmd.Process.Pid)
+	}
+	return err
+}
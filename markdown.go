@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// --- MARKDOWN -> PANGO (NOTAS DA RELEASE) ---
+
+// Tamanho máximo (em caracteres do Markdown) das notas mostradas no diálogo
+const maxReleaseNotes = 1000
+
+var (
+	headingRe = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletRe  = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	orderedRe = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+	ruleRe    = regexp.MustCompile(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	linkRe    = regexp.MustCompile(`^\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	urlRe     = regexp.MustCompile(`^https?://[^\s<>()]+[^\s<>().,;:!?'"]`)
+)
+
+// Converte as notas em Markdown para Pango, cortando em um fim de linha
+// quando passam de maxReleaseNotes. Se cortar, termina com o link da release.
+func markdownToPango(md, releaseUrl string) string {
+	return markdownToPangoLimit(md, releaseUrl, maxReleaseNotes)
+}
+
+func markdownToPangoLimit(md, releaseUrl string, limit int) string {
+	lines := strings.Split(strings.ReplaceAll(strings.TrimSpace(md), "\r\n", "\n"), "\n")
+
+	var out []string
+	size := 0
+	inCode := false
+	truncated := false
+	blank := false
+	for _, line := range lines {
+		n := utf8.RuneCountInString(line)
+		if size+n > limit {
+			// Uma única linha enorme logo no início é cortada num espaço
+			if len(out) == 0 {
+				out = append(out, convertLine(cutAtSpace(line, limit), inCode))
+			}
+			truncated = true
+			break
+		}
+		size += n
+
+		if isCodeFence(line) {
+			inCode = !inCode
+			continue
+		}
+		// Linhas em branco seguidas viram uma só
+		if strings.TrimSpace(line) == "" && !inCode {
+			if !blank && len(out) > 0 {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		out = append(out, convertLine(line, inCode))
+	}
+
+	text := strings.TrimRight(strings.Join(out, "\n"), "\n")
+	if truncated && releaseUrl == "" {
+		text += "\n\n…"
+	} else if truncated {
+		text += "\n\n" + pangoLink(releaseUrl, tr("… ver as notas completas"))
+	}
+	return text
+}
+
+func isCodeFence(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~")
+}
+
+func convertLine(line string, inCode bool) string {
+	if inCode {
+		return "<tt>" + escapeMarkup(line) + "</tt>"
+	}
+	if m := headingRe.FindStringSubmatch(line); m != nil {
+		if len(m[1]) <= 2 {
+			return "<big><b>" + inlineMarkdown(m[2]) + "</b></big>"
+		}
+		return "<b>" + inlineMarkdown(m[2]) + "</b>"
+	}
+	if ruleRe.MatchString(line) {
+		return "──────────"
+	}
+	if m := bulletRe.FindStringSubmatch(line); m != nil {
+		return listIndent(m[1]) + "• " + inlineMarkdown(m[2])
+	}
+	if m := orderedRe.FindStringSubmatch(line); m != nil {
+		return listIndent(m[1]) + m[2] + ". " + inlineMarkdown(m[3])
+	}
+	if rest, ok := strings.CutPrefix(strings.TrimSpace(line), ">"); ok {
+		return "<i>" + inlineMarkdown(strings.TrimSpace(rest)) + "</i>"
+	}
+	return inlineMarkdown(strings.TrimSpace(line))
+}
+
+// Dois espaços (ou um tab) de recuo por nível de lista
+func listIndent(lead string) string {
+	width := len(strings.ReplaceAll(lead, "\t", "  "))
+	return strings.Repeat("    ", width/2)
+}
+
+// Negrito, itálico, tachado, código e links dentro de uma linha. Marcadores
+// sem par ficam como texto.
+func inlineMarkdown(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		rest := s[i:]
+
+		if rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_[]()#+-.!~>", rune(rest[1])) {
+			b.WriteString(escapeMarkup(rest[1:2]))
+			i += 2
+			continue
+		}
+		if rest[0] == '`' {
+			if end := strings.IndexByte(rest[1:], '`'); end > 0 {
+				b.WriteString("<tt>" + escapeMarkup(rest[1:1+end]) + "</tt>")
+				i += end + 2
+				continue
+			}
+		}
+		if m := linkRe.FindStringSubmatch(rest); m != nil && isWebUrl(m[2]) {
+			b.WriteString(pangoLink(m[2], stripMarkers(m[1])))
+			i += len(m[0])
+			continue
+		}
+		if m := urlRe.FindString(rest); m != "" && (i == 0 || !isWordByte(s[i-1])) {
+			b.WriteString(pangoLink(m, m))
+			i += len(m)
+			continue
+		}
+		if inner, n, tag := emphasis(s, i); n > 0 {
+			b.WriteString("<" + tag + ">" + inlineMarkdown(inner) + "</" + tag + ">")
+			i += n
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(rest)
+		b.WriteString(escapeMarkup(string(r)))
+		i += size
+	}
+	return b.String()
+}
+
+// Reconhece **negrito**, __negrito__, *itálico*, _itálico_ e ~~tachado~~
+// começando em s[i]; retorna o conteúdo, o tamanho consumido e a tag Pango
+func emphasis(s string, i int) (string, int, string) {
+	rest := s[i:]
+	for _, e := range []struct{ marker, tag string }{
+		{"**", "b"}, {"__", "b"}, {"~~", "s"}, {"*", "i"}, {"_", "i"},
+	} {
+		if !strings.HasPrefix(rest, e.marker) {
+			continue
+		}
+		body := rest[len(e.marker):]
+		// O conteúdo não pode começar com espaço ("2 * 3 * 4")
+		if body == "" || body[0] == ' ' {
+			continue
+		}
+		// _ no meio de palavras (nomes_de_arquivo) não é ênfase
+		if e.marker[0] == '_' && i > 0 && isWordByte(s[i-1]) {
+			continue
+		}
+		end := strings.Index(body, e.marker)
+		if end <= 0 || body[end-1] == ' ' {
+			continue
+		}
+		after := i + len(e.marker)*2 + end
+		if e.marker[0] == '_' && after < len(s) && isWordByte(s[after]) {
+			continue
+		}
+		return body[:end], len(e.marker)*2 + end, e.tag
+	}
+	return "", 0, ""
+}
+
+func isWordByte(c byte) bool {
+	r := rune(c)
+	return c >= utf8.RuneSelf || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isWebUrl(u string) bool {
+	return strings.HasPrefix(u, "https://") || strings.HasPrefix(u, "http://")
+}
+
+// Texto do link sem os marcadores de ênfase
+func stripMarkers(s string) string {
+	return strings.NewReplacer("**", "", "__", "", "`", "").Replace(s)
+}
+
+func pangoLink(url, text string) string {
+	if !isWebUrl(url) {
+		return escapeMarkup(text)
+	}
+	return fmt.Sprintf(`<a href="%s">%s</a>`, escapeAttr(url), escapeMarkup(text))
+}
+
+func escapeAttr(s string) string {
+	return strings.ReplaceAll(escapeMarkup(s), `"`, "&quot;")
+}
+
+// Corta em até max caracteres, no último espaço antes do limite
+func cutAtSpace(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	cut := string(runes[:max])
+	if i := strings.LastIndexByte(cut, ' '); i > max/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ") + " …"
+}
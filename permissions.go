@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// --- PERMISSÕES DO FLATPAK ---
+
+// Lê as pastas em "flatpak override --user --show"; "!pasta" é um acesso
+// negado e aparece assim na lista
+func listFilesystemOverrides() ([]string, error) {
+	out, err := outputLogged(exec.Command("flatpak", "override", "--user", "--show", FlatpakID))
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "filesystems=") {
+			continue
+		}
+		for _, p := range strings.Split(strings.TrimPrefix(line, "filesystems="), ";") {
+			if p = strings.TrimSpace(p); p != "" {
+				paths = append(paths, p)
+			}
+		}
+	}
+	return paths, nil
+}
+
+func addFilesystemOverride(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if fi, err := os.Stat(abs); err != nil || !fi.IsDir() {
+		return fmt.Errorf(tr("pasta não encontrada: %s"), abs)
+	}
+
+	out, err := combinedLogged(exec.Command("flatpak", "override", "--user", "--filesystem="+abs, FlatpakID))
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+
+	st := loadState()
+	for _, p := range st.FilesystemOverrides {
+		if p == abs {
+			return nil
+		}
+	}
+	st.FilesystemOverrides = append(st.FilesystemOverrides, abs)
+	return saveState(st)
+}
+
+// Arquivo de overrides do usuário para o app (formato GKeyFile)
+func userOverridesFile() string {
+	dir := os.Getenv("FLATPAK_USER_DIR")
+	if dir == "" {
+		data := os.Getenv("XDG_DATA_HOME")
+		if data == "" {
+			home, _ := os.UserHomeDir()
+			data = filepath.Join(home, ".local", "share")
+		}
+		dir = filepath.Join(data, "flatpak")
+	}
+	return filepath.Join(dir, "overrides", FlatpakID)
+}
+
+// Revoga só as pastas. "--nofilesystem" gravaria uma negação permanente
+// ("!pasta") e "--reset" apagaria também os outros overrides do usuário
+// (sockets, variáveis, dispositivos...), então a chave "filesystems" é
+// removida direto do arquivo de overrides.
+func resetFilesystemOverrides() error {
+	path := userOverridesFile()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, []byte(removeFilesystemsKey(string(data))), 0644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+	}
+
+	st := loadState()
+	st.FilesystemOverrides = nil
+	return saveState(st)
+}
+
+// Tira a chave "filesystems" do grupo [Context], mantendo o resto do arquivo
+func removeFilesystemsKey(keyfile string) string {
+	var b strings.Builder
+	group := ""
+	for _, line := range strings.SplitAfter(keyfile, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			group = trimmed[1 : len(trimmed)-1]
+		} else if key, _, ok := strings.Cut(trimmed, "="); ok && group == "Context" && strings.TrimSpace(key) == "filesystems" {
+			continue
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// Reaplica as pastas salvas no estado, por exemplo após uma reinstalação
+func reapplyFilesystemOverrides() {
+	for _, p := range loadState().FilesystemOverrides {
+		if err := runLogged(exec.Command("flatpak", "override", "--user", "--filesystem="+p, FlatpakID)); err != nil {
+			slog.Warn("falha ao reaplicar a permissão", "pasta", p, "erro", err)
+		}
+	}
+}
+
+// --- TELA DE PERMISSÕES ---
+
+func handlePermissions() {
+	if !flatpakInstalled() {
+		zenityError(trf("O <b>%s</b> não está instalado via Flatpak.", AppPrettyName))
+		return
+	}
+
+	for {
+		paths, err := listFilesystemOverrides()
+		if err != nil {
+			zenityError(trf("Não foi possível ler as permissões do Flatpak:\n%v", err))
+			return
+		}
+
+		current := "<i>" + tr("Nenhuma pasta extra liberada.") + "</i>"
+		if len(paths) > 0 {
+			var b strings.Builder
+			for _, p := range paths {
+				if denied, ok := strings.CutPrefix(p, "!"); ok {
+					b.WriteString("• " + trf("%s (acesso negado)", escapeMarkup(denied)) + "\n")
+				} else {
+					b.WriteString("• " + escapeMarkup(p) + "\n")
+				}
+			}
+			current = b.String()
+		}
+		msg := trf("<b>Pastas acessíveis pelo %s (além das padrão):</b>\n\n%s"+
+			"\nUse <b>Adicionar pasta</b> para liberar, por exemplo, uma pasta de Documentos em outro disco ou a pasta do Dropbox.",
+			AppPrettyName, current)
+
+		switch zenityTripleChoice(msg, tr("Permissões do Flatpak"), tr("Adicionar pasta"), tr("Redefinir"), tr("Fechar")) {
+		case "ok":
+			out, err := exec.Command("zenity", "--file-selection", "--directory", "--title="+tr("Escolha a pasta")).Output()
+			if err != nil {
+				continue
+			}
+			if err := addFilesystemOverride(strings.TrimSpace(string(out))); err != nil {
+				zenityError(trf("Não foi possível liberar a pasta:\n%s", escapeMarkup(err.Error())))
+			}
+		case "extra":
+			if !zenityQuestionCustomTitle(trf("Remover todas as permissões de pastas do %s (liberadas ou negadas)?", AppPrettyName), tr("Redefinir permissões")) {
+				continue
+			}
+			if err := resetFilesystemOverrides(); err != nil {
+				zenityError(trf("Não foi possível redefinir as permissões:\n%s", escapeMarkup(err.Error())))
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Subcomando "permissions [list|add <pasta>|reset]"; sem argumentos abre a tela
+func runPermissionsCommand(args []string) int {
+	if len(args) == 0 {
+		ensureZenity(getDistroInfo())
+		handlePermissions()
+		return 0
+	}
+
+	switch args[0] {
+	case "list":
+		paths, err := listFilesystemOverrides()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, trf("Erro ao ler as permissões: %v", err))
+			return 1
+		}
+		for _, p := range paths {
+			fmt.Println(p)
+		}
+	case "add":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, tr("Uso: tac-installer permissions add <pasta>"))
+			return 2
+		}
+		if err := addFilesystemOverride(args[1]); err != nil {
+			fmt.Fprintln(os.Stderr, trf("Erro: %v", err))
+			return 1
+		}
+	case "reset":
+		if err := resetFilesystemOverrides(); err != nil {
+			fmt.Fprintln(os.Stderr, trf("Erro: %v", err))
+			return 1
+		}
+	default:
+		fmt.Fprintln(os.Stderr, tr("Uso: tac-installer permissions [list|add <pasta>|reset]"))
+		return 2
+	}
+	return 0
+}
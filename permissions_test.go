@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+// Só a chave "filesystems" do grupo [Context] sai do arquivo de overrides
+func TestRemoveFilesystemsKey(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{
+			"[Context]\nfilesystems=/mnt/docs;!/media/dropbox:ro;\nsockets=wayland;\n",
+			"[Context]\nsockets=wayland;\n",
+		},
+		{
+			"[Context]\nshared=network;\nfilesystems = ~/Dropbox\n\n[Environment]\nfilesystems=nao-e-do-context\n",
+			"[Context]\nshared=network;\n\n[Environment]\nfilesystems=nao-e-do-context\n",
+		},
+		{
+			"[Session Bus Policy]\norg.example=talk\n",
+			"[Session Bus Policy]\norg.example=talk\n",
+		},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := removeFilesystemsKey(tt.in); got != tt.want {
+			t.Errorf("removeFilesystemsKey(%q) = %q, esperado %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// --- REVISÃO DO PKGBUILD ---
+
+// Junta num único texto para revisão todos os arquivos do repositório
+// clonado: o makepkg também usa fontes locais, patches e scripts, e o
+// .SRCINFO define as dependências instaladas. O PKGBUILD vem primeiro;
+// arquivos binários aparecem só com o tamanho e o sha256, que também entra
+// no diff da próxima revisão.
+func collectBuildFiles(srcDir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(srcDir, "PKGBUILD")); err != nil {
+		return "", err
+	}
+
+	var files []string
+	err := filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() {
+			rel, _ := filepath.Rel(srcDir, path)
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if (files[i] == "PKGBUILD") != (files[j] == "PKGBUILD") {
+			return files[i] == "PKGBUILD"
+		}
+		return files[i] < files[j]
+	})
+
+	var b strings.Builder
+	for i, rel := range files {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "===== %s =====\n", rel)
+
+		path := filepath.Join(srcDir, rel)
+		fi, err := os.Lstat(path)
+		if err != nil {
+			return "", err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			target, _ := os.Readlink(path)
+			fmt.Fprintf(&b, "-> %s\n", target)
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		if !utf8.Valid(data) || bytes.IndexByte(data, 0) != -1 {
+			sum := sha256.Sum256(data)
+			fmt.Fprintf(&b, "%s\n", trf("(arquivo binário, %d bytes, sha256 %s)", len(data), hex.EncodeToString(sum[:])))
+			continue
+		}
+		b.Write(data)
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// Mostra os arquivos (e o diff contra a última versão aprovada) e exige
+// aprovação explícita antes de compilar
+func reviewPKGBUILD(srcDir string) bool {
+	current, err := collectBuildFiles(srcDir)
+	if err != nil {
+		zenityError(trf("Não foi possível ler o PKGBUILD:\n%s", escapeMarkup(err.Error())))
+		return false
+	}
+
+	st := loadState()
+
+	var b strings.Builder
+	switch {
+	case st.ReviewedAUR == "":
+		b.WriteString(tr("Primeira revisão deste pacote. Leia todo o conteúdo abaixo.") + "\n\n")
+	case st.ReviewedAUR == current:
+		b.WriteString(tr("Sem alterações desde a última revisão aprovada.") + "\n\n")
+	default:
+		b.WriteString(tr("ALTERAÇÕES DESDE A ÚLTIMA REVISÃO APROVADA:") + "\n\n")
+		b.WriteString(lineDiff(st.ReviewedAUR, current))
+		b.WriteString("\n\n" + tr("CONTEÚDO COMPLETO:") + "\n\n")
+	}
+	b.WriteString(current)
+
+	cmd := exec.Command("zenity", "--text-info",
+		"--title="+trf("Revisão do PKGBUILD: %s", AppName),
+		"--checkbox="+tr("Revisei o PKGBUILD e os demais arquivos e autorizo a compilação"),
+		"--ok-label="+tr("Compilar"), "--cancel-label="+tr("Cancelar"),
+		"--width=800", "--height=600")
+	cmd.Stdin = strings.NewReader(b.String())
+	if cmd.Run() != nil {
+		return false
+	}
+
+	st = loadState()
+	st.ReviewedAUR = current
+	storeState(st)
+	return true
+}
+
+// Diff de linhas simples (LCS) no formato "+ adicionada" / "- removida";
+// linhas iguais são omitidas, exceto uma de contexto em volta das mudanças
+func lineDiff(oldText, newText string) string {
+	a := strings.Split(oldText, "\n")
+	b := strings.Split(newText, "\n")
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type op struct {
+		kind byte
+		line string
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, op{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{'+', b[j]})
+	}
+
+	var out strings.Builder
+	last := -1
+	for k, o := range ops {
+		near := o.kind != ' ' ||
+			(k > 0 && ops[k-1].kind != ' ') ||
+			(k+1 < len(ops) && ops[k+1].kind != ' ')
+		if !near {
+			continue
+		}
+		if last != -1 && k > last+1 {
+			out.WriteString("...\n")
+		}
+		fmt.Fprintf(&out, "%c %s\n", o.kind, o.line)
+		last = k
+	}
+	return out.String()
+}
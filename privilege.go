@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- ELEVAÇÃO DE PRIVILÉGIO ---
+
+// Mecanismo usado para executar comandos como root
+type privilegeMethod struct {
+	Name   string   // pkexec, run0, sudo...
+	Prefix []string // colocado antes do comando
+	Env    []string // variáveis extras (ex: SUDO_ASKPASS)
+	// O sudo no terminal precisa herdar o stdin para perguntar a senha
+	UseTTY bool
+}
+
+var errNoPrivilege = errors.New(tr(`nenhum mecanismo de elevação de privilégio disponível.
+
+Para instalar pacotes do sistema o instalador precisa de um destes:
+• pkexec com um agente de autenticação do polkit em execução
+  (ex.: polkit-gnome, polkit-kde-agent, lxpolkit, mate-polkit);
+• run0 (systemd 256 ou mais novo) com um agente do polkit;
+• sudo (a senha será pedida numa janela do Zenity);
+• ou execute o instalador a partir de um terminal para usar o sudo.
+
+Alternativamente, use os formatos Flatpak (usuário), AppImage ou Local, que não precisam de root.`))
+
+var (
+	privilegeOnce   sync.Once
+	privilegeCached *privilegeMethod
+	privilegeErr    error
+)
+
+// Detecta (uma vez por execução) o mecanismo a usar, em ordem de preferência:
+// já ser root, pkexec com agente, run0 com agente, sudo com askpass gráfico
+// e por fim sudo perguntando a senha no terminal
+func detectPrivilege() (*privilegeMethod, error) {
+	privilegeOnce.Do(func() {
+		privilegeCached, privilegeErr = findPrivilegeMethod()
+	})
+	return privilegeCached, privilegeErr
+}
+
+func findPrivilegeMethod() (*privilegeMethod, error) {
+	if os.Geteuid() == 0 {
+		return &privilegeMethod{Name: "root"}, nil
+	}
+
+	agent := polkitAgentRunning()
+	tty := stdinIsTerminal()
+
+	if _, err := exec.LookPath("pkexec"); err == nil && (agent || tty) {
+		return &privilegeMethod{Name: "pkexec", Prefix: []string{"pkexec"}, UseTTY: !agent}, nil
+	}
+	if _, err := exec.LookPath("run0"); err == nil && (agent || tty) {
+		return &privilegeMethod{Name: "run0", Prefix: []string{"run0"}, UseTTY: !agent}, nil
+	}
+	if _, err := exec.LookPath("sudo"); err == nil {
+		if askpass, err := sudoAskpass(); err == nil {
+			return &privilegeMethod{
+				Name:   "sudo",
+				Prefix: []string{"sudo", "-A"},
+				Env:    []string{"SUDO_ASKPASS=" + askpass},
+			}, nil
+		}
+		if tty {
+			return &privilegeMethod{Name: "sudo", Prefix: []string{"sudo"}, UseTTY: true}, nil
+		}
+	}
+	return nil, errNoPrivilege
+}
+
+// Nomes de processos de agentes do polkit (e ambientes com agente embutido)
+var polkitAgents = []string{
+	"polkit", "policykit", "lxpolkit", "gnome-shell", "cinnamon",
+	"budgie-polkit-dialog", "pantheon-agent-polkit", "soteria",
+}
+
+// Procura em /proc um agente do polkit rodando com o usuário atual
+func polkitAgentRunning() bool {
+	procs, _ := filepath.Glob("/proc/[0-9]*/cmdline")
+	uid := os.Getuid()
+	for _, p := range procs {
+		fi, err := os.Stat(filepath.Dir(p))
+		if err != nil || fileOwner(fi) != uid {
+			continue
+		}
+		data, err := os.ReadFile(p)
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		exe := strings.ToLower(filepath.Base(strings.SplitN(string(data), "\x00", 2)[0]))
+		if exe == "polkitd" {
+			continue
+		}
+		for _, name := range polkitAgents {
+			if strings.Contains(exe, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Usa o SUDO_ASKPASS do usuário ou cria um que pede a senha pelo Zenity
+func sudoAskpass() (string, error) {
+	if env := os.Getenv("SUDO_ASKPASS"); env != "" {
+		return env, nil
+	}
+	if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return "", errors.New(tr("sem sessão gráfica"))
+	}
+	if _, err := exec.LookPath("zenity"); err != nil {
+		return "", err
+	}
+
+	path, err := tempPath("askpass.sh")
+	if err != nil {
+		return "", err
+	}
+	script := "#!/bin/sh\nexec zenity --password " + shellQuote("--title="+tr("Autenticação necessária")) + "\n"
+	if err := os.WriteFile(path, []byte(script), 0700); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Comando completo (mecanismo + comando) para execução como root
+func (m *privilegeMethod) argv(name string, args ...string) []string {
+	return append(append(append([]string{}, m.Prefix...), name), args...)
+}
+
+// Executa como root usando o mecanismo detectado, pelo processo auxiliar
+// quando possível. Todo comando como root é uma transação do gerenciador de
+// pacotes: uma vez iniciado, vai até o fim mesmo com ctx cancelado.
+type privilegedRunner struct{}
+
+func (privilegedRunner) Run(ctx context.Context, out io.Writer, name string, args ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	defer trackCommand(true)()
+
+	m, err := detectPrivilege()
+	if err != nil {
+		return err
+	}
+	h, err := getHelper(m)
+	if err != nil {
+		return err
+	}
+	argv := m.argv(name, args...)
+	if h != nil {
+		capture := &tailBuffer{}
+		start := time.Now()
+		err := h.Run(teeTo(out, capture), name, args...)
+		logCommand(argv, err, capture.String(), time.Since(start))
+		return err
+	}
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = append(os.Environ(), m.Env...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	restore := shieldFromTerminal(cmd, m.UseTTY)
+	defer restore()
+	return runLogged(cmd)
+}
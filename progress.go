@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// --- PROGRESSO DOS GERENCIADORES DE PACOTES ---
+
+var (
+	// apt com -o APT::Status-Fd=1: "dlstatus:1:12.5:Baixando ..." / "pmstatus:tac-writer:45:Instalando ..."
+	aptStatusRe = regexp.MustCompile(`^(dl|pm)status:[^:]*:([\d.]+):(.*)$`)
+	// dnf: "Installing : tac-writer-1.3-1.noarch   1/2"; dnf5: "[1/2] Installing ..."
+	dnfStepRe = regexp.MustCompile(`^\[(\d+)/(\d+)\]|\s(\d+)/(\d+)\s*$`)
+	// zypper: "Retrieving: tac-writer (1/2)" / "(1/2) Installing: tac-writer"
+	zypperStepRe = regexp.MustCompile(`\((\d+)/(\d+)\)`)
+)
+
+// Converte uma linha da saída em porcentagem (-1 se não houver) e no texto a
+// mostrar na janela
+type progressParser func(line string) (pct int, msg string)
+
+func progressParserFor(name string) progressParser {
+	switch filepath.Base(name) {
+	case "apt":
+		return parseAptProgress
+	case "dnf", "dnf5":
+		return parseDnfProgress
+	case "zypper":
+		return parseZypperProgress
+	case "flatpak":
+		return parsePercentProgress
+	}
+	return nil
+}
+
+// Download ocupa a primeira metade da barra e o dpkg a segunda
+func parseAptProgress(line string) (int, string) {
+	m := aptStatusRe.FindStringSubmatch(line)
+	if m == nil {
+		return -1, line
+	}
+	f, _ := strconv.ParseFloat(m[2], 64)
+	pct := int(f) / 2
+	if m[1] == "pm" {
+		pct += 50
+	}
+	return pct, m[3]
+}
+
+// A verificação final ocupa os últimos 10%
+func parseDnfProgress(line string) (int, string) {
+	m := dnfStepRe.FindStringSubmatch(line)
+	if m == nil {
+		return -1, line
+	}
+	n, total := m[1], m[2]
+	if n == "" {
+		n, total = m[3], m[4]
+	}
+	if strings.Contains(line, "Verif") {
+		return 90 + stepPercent(n, total, 10), line
+	}
+	return stepPercent(n, total, 90), line
+}
+
+func parseZypperProgress(line string) (int, string) {
+	m := zypperStepRe.FindStringSubmatch(line)
+	if m == nil {
+		return -1, line
+	}
+	if strings.HasPrefix(strings.TrimSpace(line), "Retrieving") {
+		return stepPercent(m[1], m[2], 50), line
+	}
+	return 50 + stepPercent(m[1], m[2], 50), line
+}
+
+func parsePercentProgress(line string) (int, string) {
+	if m := progressRe.FindStringSubmatch(line); m != nil {
+		pct, _ := strconv.Atoi(m[1])
+		return pct, line
+	}
+	return -1, line
+}
+
+// Passo n de total convertido numa fatia de tamanho span
+func stepPercent(n, total string, span int) int {
+	a, _ := strconv.Atoi(n)
+	b, _ := strconv.Atoi(total)
+	if b <= 0 || a > b {
+		return -1
+	}
+	return a * span / b
+}
+
+// Writer que guarda toda a saída e chama onLine a cada linha completa,
+// aceitando "\r" como separador (barras de progresso)
+type lineWriter struct {
+	mu     sync.Mutex
+	log    bytes.Buffer
+	buf    []byte
+	onLine func(line string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.log.Write(p)
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexAny(w.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Entrega a última linha sem quebra
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.emit(w.buf)
+	w.buf = nil
+}
+
+func (w *lineWriter) emit(b []byte) {
+	if line := strings.TrimSpace(string(b)); line != "" && w.onLine != nil {
+		w.onLine(line)
+	}
+}
+
+func (w *lineWriter) Bytes() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.log.Bytes()
+}
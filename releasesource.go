@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// --- ORIGEM DAS RELEASES ---
+
+const (
+	SourceGithub           = "github"
+	SourceGithubEnterprise = "github-enterprise"
+	SourceGitea            = "gitea"
+	SourceForgejo          = "forgejo"
+	SourceGitlab           = "gitlab"
+	SourceStatic           = "static"
+)
+
+const (
+	releasesPerPage = 50
+	// Limite de páginas consultadas ao procurar a versão instalada
+	maxReleasePages = 5
+)
+
+// De onde vêm as releases do Tac Writer. Toda origem devolve as releases no
+// formato da API do GitHub.
+type ReleaseSource interface {
+	// Release mais nova, sem rascunhos nem pré-lançamentos
+	Latest(ctx context.Context) (*GithubRelease, error)
+	// Releases da mais nova para a mais antiga, até chegar numa versão igual
+	// ou anterior a since
+	ReleasesSince(ctx context.Context, since string) ([]GithubRelease, error)
+	// Página com a lista de releases ("" se não houver)
+	ReleasesPage() string
+}
+
+// Origem escolhida em "source" no config.json; sem configuração, o GitHub
+func releaseSource() (ReleaseSource, error) {
+	cfg := loadConfig().Source
+	repo := strings.Trim(cfg.Repo, "/")
+	if repo == "" {
+		repo = GithubUser + "/" + AppName
+	}
+	base := strings.TrimRight(cfg.Url, "/")
+	// Os pacotes listados pela origem são instalados como root
+	if cfg.Url != "" && (!isWebUrl(cfg.Url) || !strings.HasPrefix(cfg.Url, "https://")) {
+		return nil, fmt.Errorf(tr("endereço inválido em \"source.url\" no %s (é preciso usar https://): %s"), getConfigFile(), cfg.Url)
+	}
+
+	switch cfg.Type {
+	case "", SourceGithub:
+		return newGithubSource("", repo, cfg.Token), nil
+	case SourceGithubEnterprise:
+		if base == "" {
+			return nil, fmt.Errorf(tr("a origem %s precisa de \"source.url\" no %s"), cfg.Type, getConfigFile())
+		}
+		return newGithubSource(base, repo, cfg.Token), nil
+	case SourceGitea, SourceForgejo:
+		if base == "" {
+			return nil, fmt.Errorf(tr("a origem %s precisa de \"source.url\" no %s"), cfg.Type, getConfigFile())
+		}
+		name := "Gitea"
+		if cfg.Type == SourceForgejo {
+			name = "Forgejo"
+		}
+		return &giteaSource{name: name, base: base, repo: repo, token: cfg.Token}, nil
+	case SourceGitlab:
+		if base == "" {
+			base = "https://gitlab.com"
+		}
+		return &gitlabSource{base: base, project: repo, token: cfg.Token}, nil
+	case SourceStatic:
+		if base == "" {
+			return nil, fmt.Errorf(tr("a origem %s precisa de \"source.url\" no %s"), cfg.Type, getConfigFile())
+		}
+		return &staticSource{url: cfg.Url}, nil
+	}
+	return nil, fmt.Errorf(tr("origem de releases desconhecida no %s: %s"), getConfigFile(), cfg.Type)
+}
+
+func getLatestRelease(ctx context.Context) (*GithubRelease, error) {
+	src, err := releaseSource()
+	if err != nil {
+		return nil, err
+	}
+	return src.Latest(ctx)
+}
+
+// Consulta uma API JSON (com o cache por ETag) e decodifica a resposta em v.
+// Com o limite de consultas atingido, usa a última resposta guardada se houver.
+func apiGet(ctx context.Context, service, url string, header http.Header, hint string, v any) error {
+	status, respHeader, body, err := cachedGet(ctx, url, header)
+	if err != nil {
+		return err
+	}
+
+	if rl := rateLimitFromResponse(status, respHeader); rl != nil {
+		rl.Service, rl.Hint = service, hint
+		slog.Warn("limite de consultas da API", "url", url, "reset", rl.Reset)
+		if cached := readApiCache(url); cached != nil {
+			slog.Warn("usando a resposta guardada", "url", url, "de", cached.Time)
+			return json.Unmarshal(cached.Body, v)
+		}
+		return rl
+	}
+	switch status {
+	case http.StatusOK:
+		return json.Unmarshal(body, v)
+	case http.StatusUnauthorized:
+		return fmt.Errorf(tr("o %s recusou o token de acesso (401); confira o token configurado"), service)
+	}
+	return fmt.Errorf(tr("%s retornou erro %d"), service, status)
+}
+
+// Busca páginas de releases até chegar numa versão igual ou anterior a since
+func pagedReleases(since string, fetch func(page int) ([]GithubRelease, error)) ([]GithubRelease, error) {
+	var all []GithubRelease
+	for page := 1; page <= maxReleasePages; page++ {
+		releases, err := fetch(page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, releases...)
+		if len(releases) < releasesPerPage {
+			break
+		}
+		last := strings.TrimPrefix(releases[len(releases)-1].TagName, "v")
+		if compareVersions(last, since) <= 0 {
+			break
+		}
+	}
+	return all, nil
+}
+
+// Release mais nova (pela versão), sem rascunhos nem pré-lançamentos
+func newestRelease(releases []GithubRelease) (*GithubRelease, error) {
+	var newest *GithubRelease
+	for i := range releases {
+		r := &releases[i]
+		if r.Draft || r.Prerelease {
+			continue
+		}
+		if newest == nil || compareVersions(strings.TrimPrefix(r.TagName, "v"), strings.TrimPrefix(newest.TagName, "v")) > 0 {
+			newest = r
+		}
+	}
+	if newest == nil {
+		return nil, errors.New(tr("nenhuma release publicada"))
+	}
+	return newest, nil
+}
+
+// --- GITEA / FORGEJO ---
+
+// A API do Gitea (e do Forgejo) devolve as releases no mesmo formato do GitHub
+type giteaSource struct {
+	name  string
+	base  string
+	repo  string
+	token string
+}
+
+func (s *giteaSource) get(ctx context.Context, path string, v any) error {
+	h := http.Header{}
+	h.Set("User-Agent", "Go-Installer-Zenity")
+	h.Set("Accept", "application/json")
+	if s.token != "" {
+		h.Set("Authorization", "token "+s.token)
+	}
+	return apiGet(ctx, s.name, s.base+"/api/v1/repos/"+s.repo+path, h, "", v)
+}
+
+func (s *giteaSource) Latest(ctx context.Context) (*GithubRelease, error) {
+	var release GithubRelease
+	if err := s.get(ctx, "/releases/latest", &release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+func (s *giteaSource) ReleasesSince(ctx context.Context, since string) ([]GithubRelease, error) {
+	return pagedReleases(since, func(page int) ([]GithubRelease, error) {
+		var releases []GithubRelease
+		err := s.get(ctx, "/releases?limit="+strconv.Itoa(releasesPerPage)+"&page="+strconv.Itoa(page), &releases)
+		return releases, err
+	})
+}
+
+func (s *giteaSource) ReleasesPage() string {
+	return s.base + "/" + s.repo + "/releases"
+}
+
+// --- GITLAB ---
+
+type gitlabSource struct {
+	base    string
+	project string // caminho do projeto, ex.: grupo/tac-writer
+	token   string
+}
+
+type gitlabRelease struct {
+	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ReleasedAt  string `json:"released_at"`
+	Upcoming    bool   `json:"upcoming_release"`
+	Links       struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+	Assets struct {
+		Sources []struct {
+			Format string `json:"format"`
+			Url    string `json:"url"`
+		} `json:"sources"`
+		Links []struct {
+			Name           string `json:"name"`
+			Url            string `json:"url"`
+			DirectAssetUrl string `json:"direct_asset_url"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+func (r gitlabRelease) toGithub() GithubRelease {
+	g := GithubRelease{
+		TagName:     r.TagName,
+		Name:        r.Name,
+		Body:        r.Description,
+		PublishedAt: r.ReleasedAt,
+		HtmlUrl:     r.Links.Self,
+		Prerelease:  r.Upcoming,
+	}
+	for _, src := range r.Assets.Sources {
+		if src.Format == "tar.gz" {
+			g.TarballUrl = src.Url
+		}
+	}
+	for _, l := range r.Assets.Links {
+		u := l.DirectAssetUrl
+		if u == "" {
+			u = l.Url
+		}
+		g.Assets = append(g.Assets, GithubAsset{Name: l.Name, BrowserDownloadUrl: u})
+	}
+	return g
+}
+
+func (s *gitlabSource) page(ctx context.Context, page int) ([]GithubRelease, error) {
+	h := http.Header{}
+	h.Set("User-Agent", "Go-Installer-Zenity")
+	if s.token != "" {
+		h.Set("PRIVATE-TOKEN", s.token)
+	}
+	u := fmt.Sprintf("%s/api/v4/projects/%s/releases?per_page=%d&page=%d", s.base, url.PathEscape(s.project), releasesPerPage, page)
+
+	var list []gitlabRelease
+	if err := apiGet(ctx, "GitLab", u, h, "", &list); err != nil {
+		return nil, err
+	}
+	releases := make([]GithubRelease, 0, len(list))
+	for _, r := range list {
+		releases = append(releases, r.toGithub())
+	}
+	return releases, nil
+}
+
+// O GitLab lista as releases da mais nova para a mais antiga
+func (s *gitlabSource) Latest(ctx context.Context) (*GithubRelease, error) {
+	releases, err := s.page(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	return newestRelease(releases)
+}
+
+func (s *gitlabSource) ReleasesSince(ctx context.Context, since string) ([]GithubRelease, error) {
+	return pagedReleases(since, func(page int) ([]GithubRelease, error) {
+		return s.page(ctx, page)
+	})
+}
+
+func (s *gitlabSource) ReleasesPage() string {
+	return s.base + "/" + s.project + "/-/releases"
+}
+
+// --- ÍNDICE JSON ESTÁTICO ---
+
+// Arquivo JSON em qualquer servidor HTTP com a lista de releases no formato
+// da API do GitHub. Endereços relativos dos arquivos são resolvidos a partir
+// do endereço do índice, o que permite espelhar as releases numa pasta só.
+type staticSource struct {
+	url string
+}
+
+func (s *staticSource) all(ctx context.Context) ([]GithubRelease, error) {
+	h := http.Header{}
+	h.Set("User-Agent", "Go-Installer-Zenity")
+
+	var releases []GithubRelease
+	if err := apiGet(ctx, tr("servidor de releases"), s.url, h, "", &releases); err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(s.url)
+	if err != nil {
+		return nil, err
+	}
+	resolve := func(ref string) string {
+		if ref == "" {
+			return ""
+		}
+		u, err := base.Parse(ref)
+		if err != nil {
+			return ref
+		}
+		return u.String()
+	}
+	for i := range releases {
+		r := &releases[i]
+		r.TarballUrl = resolve(r.TarballUrl)
+		r.HtmlUrl = resolve(r.HtmlUrl)
+		for j := range r.Assets {
+			r.Assets[j].BrowserDownloadUrl = resolve(r.Assets[j].BrowserDownloadUrl)
+		}
+	}
+	sort.SliceStable(releases, func(i, j int) bool {
+		return compareVersions(strings.TrimPrefix(releases[i].TagName, "v"), strings.TrimPrefix(releases[j].TagName, "v")) > 0
+	})
+	return releases, nil
+}
+
+func (s *staticSource) Latest(ctx context.Context) (*GithubRelease, error) {
+	releases, err := s.all(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newestRelease(releases)
+}
+
+func (s *staticSource) ReleasesSince(ctx context.Context, since string) ([]GithubRelease, error) {
+	return s.all(ctx)
+}
+
+func (s *staticSource) ReleasesPage() string {
+	return ""
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// --- RELATÓRIO DE DIAGNÓSTICO ---
+
+var reportButton = tr("Salvar relatório de diagnóstico")
+
+// Linhas finais de cada log incluídas no relatório
+const reportLogLines = 300
+
+// Último erro mostrado ao usuário, incluído no relatório
+var lastError string
+
+func rememberError(text string) {
+	lastError = text
+}
+
+var (
+	tokenRe = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,}|glpat-[A-Za-z0-9_-]{20,})\b`)
+	authRe  = regexp.MustCompile(`(?i)(authorization|token|password|senha)(["']?\s*[:=]\s*["']?)[^\s"',]+`)
+)
+
+// Remove dados pessoais: pasta pessoal, nome de usuário, nome da máquina e tokens
+func redact(s string) string {
+	s = tokenRe.ReplaceAllString(s, "<token>")
+	s = authRe.ReplaceAllString(s, "$1$2<removido>")
+	if home, err := os.UserHomeDir(); err == nil && home != "/" {
+		s = strings.ReplaceAll(s, home, "~")
+	}
+	if u, err := user.Current(); err == nil && len(u.Username) > 2 && u.Username != "root" {
+		s = strings.ReplaceAll(s, u.Username, "<usuário>")
+	}
+	if host, err := os.Hostname(); err == nil && len(host) > 2 {
+		s = strings.ReplaceAll(s, host, "<máquina>")
+	}
+	return s
+}
+
+func tailLines(path string, n int) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Log mais recente de compilação do AUR
+func latestBuildLog() string {
+	logs, _ := filepath.Glob(filepath.Join(getStateDir(), "logs", "aur-build-*.log"))
+	if len(logs) == 0 {
+		return ""
+	}
+	sort.Strings(logs)
+	return logs[len(logs)-1]
+}
+
+func commandLine(name string, args ...string) string {
+	out, err := outputLogged(exec.Command(name, args...))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(nenhum)"
+	}
+	return s
+}
+
+// Monta o relatório em Markdown, já sem dados pessoais. O texto não é
+// traduzido: o relatório é lido pelos mantenedores.
+func buildReport() string {
+	var b strings.Builder
+	section := func(title string) { fmt.Fprintf(&b, "\n## %s\n\n", title) }
+	item := func(name, value string) { fmt.Fprintf(&b, "- **%s**: %s\n", name, orNone(value)) }
+	code := func(text string) { fmt.Fprintf(&b, "```\n%s\n```\n", orNone(text)) }
+
+	fmt.Fprintf(&b, "# Relatório de diagnóstico do tac-installer\n\nGerado em %s\n", time.Now().Format(time.RFC3339))
+
+	d := getDistroInfo()
+	section("Sistema")
+	item("Distribuição", d.Pretty)
+	item("ID", d.ID)
+	item("ID_LIKE", d.IDLike)
+	item("Arquitetura", runtime.GOARCH+" ("+commandLine("uname", "-m")+")")
+	item("Kernel", commandLine("uname", "-r"))
+	item("Sessão", os.Getenv("XDG_SESSION_TYPE")+" "+os.Getenv("XDG_CURRENT_DESKTOP"))
+
+	section("Gerenciadores de pacotes")
+	if bk := detectNativeBackend(d); bk != nil {
+		item("Detectado", bk.Manager+" ("+bk.Pretty+")")
+	} else {
+		item("Detectado", "")
+	}
+	var found []string
+	for _, bb := range backendBinaries {
+		if _, err := exec.LookPath(bb.bin); err == nil {
+			found = append(found, bb.bin)
+		}
+	}
+	item("Disponíveis", strings.Join(found, ", "))
+	if m, err := detectPrivilege(); err == nil {
+		item("Elevação de privilégio", m.Name)
+	} else {
+		item("Elevação de privilégio", "")
+	}
+
+	section("Terminais")
+	var terms []string
+	for _, t := range knownTerminals {
+		if _, err := exec.LookPath(t.cmd); err == nil {
+			terms = append(terms, t.cmd)
+		}
+	}
+	item("Encontrados", strings.Join(terms, ", "))
+	if t := findTerminal(); t != nil {
+		item("Escolhido", t.cmd)
+	}
+	item("$TERMINAL", os.Getenv("TERMINAL"))
+
+	section("Flatpak")
+	if _, err := exec.LookPath("flatpak"); err == nil {
+		item("Versão", commandLine("flatpak", "--version"))
+		b.WriteString("\nRemotes:\n\n")
+		code(commandLine("flatpak", "remotes", "--show-details"))
+	} else {
+		item("Versão", "não instalado")
+	}
+
+	section("Versões instaladas")
+	st := loadState()
+	item("Formato registrado", st.Format)
+	item("Versão registrada", st.Version)
+	fileVersion, _ := getInstalledVersion()
+	item("version.txt", fileVersion)
+	item("Pacote nativo", queryNativeVersion(d))
+	item("Flatpak (usuário)", flatpakInstalledVersion(ScopeUser))
+	item("Flatpak (sistema)", flatpakInstalledVersion(ScopeSystem))
+	if appImageInstalled() {
+		item("AppImage", getAppImagePath())
+	}
+
+	// Sem o token: o relatório é público
+	section("Origem das releases")
+	src := loadConfig().Source
+	if src.Type == "" {
+		src.Type = SourceGithub
+	}
+	item("Tipo", src.Type)
+	item("Endereço", src.Url)
+	item("Repositório", src.Repo)
+
+	section("Arquivo de estado")
+	data, _ := os.ReadFile(getStateFile())
+	code(strings.TrimSpace(string(data)))
+
+	if lastError != "" {
+		section("Último erro")
+		code(lastError)
+	}
+
+	section("Log do instalador (últimas linhas)")
+	code(tailLines(getLogFile(), reportLogLines))
+
+	if path := latestBuildLog(); path != "" {
+		section("Log da última compilação do AUR (" + filepath.Base(path) + ")")
+		code(tailLines(path, reportLogLines))
+	}
+
+	return redact(b.String())
+}
+
+// Grava o relatório em Markdown ou, se o nome terminar em .tar.gz/.tgz, num
+// tarball com o relatório e os logs completos (também sem dados pessoais)
+func writeReport(path string) error {
+	report := buildReport()
+	if !strings.HasSuffix(path, ".tar.gz") && !strings.HasSuffix(path, ".tgz") {
+		return os.WriteFile(path, []byte(report), 0600)
+	}
+
+	files := map[string]string{"relatorio.md": report}
+	if data, err := os.ReadFile(getLogFile()); err == nil {
+		files["installer.log"] = redact(string(data))
+	}
+	if data, err := os.ReadFile(getStateFile()); err == nil {
+		files["state.json"] = redact(string(data))
+	}
+	if p := latestBuildLog(); p != "" {
+		if data, err := os.ReadFile(p); err == nil {
+			files[filepath.Base(p)] = redact(string(data))
+		}
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		hdr := &tar.Header{Name: "tac-installer-relatorio/" + name, Mode: 0644, Size: int64(len(files[name])), ModTime: time.Now()}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write([]byte(files[name])); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0600)
+}
+
+func defaultReportPath(ext string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.TempDir()
+	}
+	return filepath.Join(home, "tac-installer-relatorio-"+time.Now().Format("20060102-150405")+ext)
+}
+
+// Pergunta onde salvar (botão dos diálogos de erro)
+func saveReportDialog() {
+	out, err := exec.Command("zenity", "--file-selection", "--save", "--confirm-overwrite",
+		"--title="+reportButton,
+		"--filename="+defaultReportPath(".md"),
+		"--file-filter=Markdown (.md) | *.md",
+		"--file-filter="+tr("Tarball com os logs (.tar.gz)")+" | *.tar.gz").Output()
+	if err != nil {
+		return
+	}
+	path := strings.TrimSpace(string(out))
+	if err := writeReport(path); err != nil {
+		exec.Command("zenity", "--error", "--text="+trf("Erro ao salvar o relatório:\n%s", escapeMarkup(err.Error())), "--width=400").Run()
+		return
+	}
+	zenityInfo(trf("Relatório salvo em:\n<small>%s</small>\n\nDados pessoais (pasta pessoal, usuário, nome da máquina e tokens) foram removidos. Revise o arquivo antes de anexá-lo a uma issue.", escapeMarkup(path)))
+}
+
+// tac-installer report [arquivo.md|arquivo.tar.gz]
+func runReportCommand(args []string) int {
+	if len(args) > 1 {
+		usage()
+		return 2
+	}
+	path := defaultReportPath(".md")
+	if len(args) == 1 {
+		path = args[0]
+	}
+	if err := writeReport(path); err != nil {
+		fmt.Fprintln(os.Stderr, trf("Erro ao gerar o relatório: %v", err))
+		return 1
+	}
+	fmt.Println(path)
+	return 0
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+)
+
+// --- EXECUÇÃO DE COMANDOS ---
+
+// Runner executa um comando como vetor de argumentos, nunca via shell, de modo
+// que nomes de arquivo com aspas, espaços ou ";" chegam intactos ao programa.
+type Runner interface {
+	// Executa o comando repassando stdout e stderr para out. Cancelar ctx
+	// encerra o comando, exceto transações do gerenciador de pacotes.
+	Run(ctx context.Context, out io.Writer, name string, args ...string) error
+}
+
+// Executa como o usuário atual
+type userRunner struct{}
+
+func (userRunner) Run(ctx context.Context, out io.Writer, name string, args ...string) error {
+	defer trackCommand(false)()
+	cmd := exec.CommandContext(ctx, name, args...)
+	killGroupOnCancel(cmd)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	err := runLogged(cmd)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func runnerFor(needsRoot bool) Runner {
+	if needsRoot {
+		return privilegedRunner{}
+	}
+	return userRunner{}
+}
+
+// Executa e devolve a saída combinada, como exec.Cmd.CombinedOutput
+func combinedOutput(ctx context.Context, r Runner, name string, args ...string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := r.Run(ctx, &buf, name, args...)
+	return buf.Bytes(), err
+}
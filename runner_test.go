@@ -0,0 +1,20 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// Cada argumento chega intacto ao programa, sem passar por um shell
+func TestUserRunnerArgv(t *testing.T) {
+	for _, name := range hostileNames {
+		var out bytes.Buffer
+		if err := (userRunner{}).Run(context.Background(), &out, "printf", "%s\n", name); err != nil {
+			t.Fatalf("printf %q: %v", name, err)
+		}
+		if got := out.String(); got != name+"\n" {
+			t.Errorf("argumento %q chegou como %q", name, got)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// --- ESTADO DO INSTALADOR ---
+
+const (
+	FormatNative   = "Nativo"
+	FormatFlatpak  = "Flatpak"
+	FormatAppImage = "AppImage"
+	FormatLocal    = "Local"
+)
+
+type InstallerState struct {
+	Format        string `json:"format,omitempty"`
+	Version       string `json:"version,omitempty"`
+	AppImagePath  string `json:"appimage_path,omitempty"`
+	FlatpakOrigin string `json:"flatpak_origin,omitempty"`
+	FlatpakScope  string `json:"flatpak_scope,omitempty"`
+
+	// Pastas liberadas com "flatpak override", reaplicadas após reinstalar
+	FilesystemOverrides []string `json:"filesystem_overrides,omitempty"`
+
+	// Arquivos do repositório do AUR aprovados na última revisão
+	ReviewedAUR string `json:"reviewed_aur,omitempty"`
+}
+
+func getStateDir() string {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "tac-installer")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "tac-installer")
+	}
+	return filepath.Join(home, ".local", "state", "tac-installer")
+}
+
+func getStateFile() string {
+	return filepath.Join(getStateDir(), "state.json")
+}
+
+func loadState() InstallerState {
+	var st InstallerState
+	data, err := os.ReadFile(getStateFile())
+	if err != nil {
+		return st
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		slog.Warn("arquivo de estado inválido", "arquivo", getStateFile(), "erro", err)
+	}
+	return st
+}
+
+func saveState(st InstallerState) error {
+	if err := os.MkdirAll(getStateDir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := getStateFile() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, getStateFile())
+}
+
+// Salva o estado registrando no log uma eventual falha
+func storeState(st InstallerState) {
+	if err := saveState(st); err != nil {
+		slog.Error("falha ao salvar o estado", "arquivo", getStateFile(), "erro", err)
+	}
+}
+
+// Registra a versão e o formato usados na última instalação bem-sucedida
+func recordInstall(format, version string) {
+	writeInstalledVersion(version)
+	st := loadState()
+	st.Format = format
+	st.Version = version
+	storeState(st)
+}
+
+func clearInstallRecord() {
+	removeVersionFile()
+	st := loadState()
+	st.Format = ""
+	st.Version = ""
+	st.AppImagePath = ""
+	st.FlatpakOrigin = ""
+	st.FlatpakScope = ""
+	storeState(st)
+}
+
+// Formato da instalação existente: usa o estado salvo e, para instalações
+// antigas sem estado, tenta detectar pelo sistema.
+func installedFormat() string {
+	if st := loadState(); st.Format != "" {
+		return st.Format
+	}
+	if flatpakInstalled() {
+		return FormatFlatpak
+	}
+	if appImageInstalled() {
+		return FormatAppImage
+	}
+	if localInstalled() {
+		return FormatLocal
+	}
+	if nativeInstalled() {
+		return FormatNative
+	}
+	return ""
+}
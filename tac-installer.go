@@ -2,13 +2,19 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -38,7 +44,11 @@ type GithubRelease struct {
 	TagName     string        `json:"tag_name"`
 	Name        string        `json:"name"`
 	Body        string        `json:"body"`
+	Draft       bool          `json:"draft"`
+	Prerelease  bool          `json:"prerelease"`
 	PublishedAt string        `json:"published_at"`
+	TarballUrl  string        `json:"tarball_url"`
+	HtmlUrl     string        `json:"html_url"`
 	Assets[]GithubAsset `json:"assets"`
 }
 
@@ -54,7 +64,9 @@ func writeInstalledVersion(version string) {
 	_ = os.MkdirAll(AppInstallDir, 0755)
 	vFile := getVersionFile()
 	_ = os.MkdirAll(filepath.Dir(vFile), 0755)
-	_ = os.WriteFile(vFile,[]byte(version), 0644)
+	if err := os.WriteFile(vFile, []byte(version), 0644); err != nil {
+		slog.Warn("falha ao gravar a versão instalada", "arquivo", vFile, "erro", err)
+	}
 }
 
 func getInstalledVersion() (string, error) {
@@ -101,13 +113,9 @@ func compareVersions(a, b string) int {
 	return 0
 }
 
-func checkIsInstalled() bool {
-	// 1. Verifica Flatpak
-	if err := exec.Command("flatpak", "info", FlatpakID).Run(); err == nil {
-		return true
-	}
-	// 2. Verifica Nativo
-	if _, err := exec.LookPath("tac-writer"); err == nil {
+func nativeInstalled() bool {
+	// Ignora o lançador da instalação local, que também se chama tac-writer
+	if path, err := exec.LookPath("tac-writer"); err == nil && path != getLocalLauncherPath() {
 		return true
 	}
 	path := filepath.Join(AppInstallDir, "main.py")
@@ -115,41 +123,41 @@ func checkIsInstalled() bool {
 	return err == nil
 }
 
-func openApplication() {
-	// Dá preferência para rodar Flatpak se estiver instalado, senão Nativo
-	if err := exec.Command("flatpak", "info", FlatpakID).Run(); err == nil {
-		exec.Command("flatpak", "run", FlatpakID).Start()
-		return
+func checkIsInstalled() bool {
+	// 1. Verifica Flatpak
+	if flatpakInstalled() {
+		return true
 	}
-	cmd := exec.Command("tac-writer")
-	if err := cmd.Start(); err != nil {
-		exec.Command("python3", filepath.Join(AppInstallDir, "main.py")).Start()
+	// 2. Verifica AppImage
+	if appImageInstalled() {
+		return true
 	}
+	// 3. Verifica instalação local
+	if localInstalled() {
+		return true
+	}
+	// 4. Verifica Nativo
+	return nativeInstalled()
 }
 
-func getLatestRelease(user, repo string) (*GithubRelease, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases/latest", user, repo)
-
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("User-Agent", "Go-Installer-Zenity")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+func openApplication() {
+	if appImageInstalled() {
+		startLogged(exec.Command(getAppImagePath()))
+		return
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("GitHub retornou erro %d", resp.StatusCode)
+	if localInstalled() {
+		startLogged(exec.Command(getLocalLauncherPath()))
+		return
 	}
-
-	var release GithubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil, err
+	// Dá preferência para rodar Flatpak se estiver instalado, senão Nativo
+	if flatpakInstalled() {
+		runFlatpak()
+		return
+	}
+	cmd := exec.Command("tac-writer")
+	if err := startLogged(cmd); err != nil {
+		startLogged(exec.Command("python3", filepath.Join(AppInstallDir, "main.py")))
 	}
-
-	return &release, nil
 }
 
 func findAssetUrl(release *GithubRelease, suffix string) (string, string, error) {
@@ -158,25 +166,47 @@ func findAssetUrl(release *GithubRelease, suffix string) (string, string, error)
 			if strings.Contains(asset.Name, "arm") || strings.Contains(asset.Name, "aarch64") {
 				continue
 			}
+			if !strings.HasPrefix(asset.BrowserDownloadUrl, "https://") {
+				return "", "", fmt.Errorf(tr("o arquivo %s não usa https://: %s"), asset.Name, asset.BrowserDownloadUrl)
+			}
 			return asset.Name, asset.BrowserDownloadUrl, nil
 		}
 	}
-	return "", "", fmt.Errorf("nenhum arquivo %s encontrado", suffix)
+	return "", "", fmt.Errorf(tr("nenhum arquivo %s encontrado"), suffix)
 }
 
-func formatReleaseNotes(body string) string {
-	body = strings.ReplaceAll(body, "&", "&amp;")
-	body = strings.ReplaceAll(body, "<", "&lt;")
-	body = strings.ReplaceAll(body, ">", "&gt;")
-	body = strings.TrimSpace(body)
+// Nome do asset como veio da release não é confiável: remove diretórios e
+// impede que comece com "-" (seria lido como opção pelo gerenciador)
+func safeFileName(name string) string {
+	name = filepath.Base(filepath.Clean("/" + name))
+	name = strings.TrimLeft(name, "-.")
+	if name == "" || name == "/" {
+		name = AppName + "-download"
+	}
+	return name
+}
+
+func hasAsset(release *GithubRelease, suffix string) bool {
+	_, _, err := findAssetUrl(release, suffix)
+	return err == nil
+}
 
-	if body == "" {
-		return "Nenhuma descrição fornecida."
+// Notas da release em Pango, com o link para a página da release se cortadas
+func formatReleaseNotes(release *GithubRelease) string {
+	if strings.TrimSpace(release.Body) == "" {
+		return tr("Nenhuma descrição fornecida.")
 	}
-	if len(body) > 1000 {
-		body = body + "\n\n... (ver mais no GitHub)"
+	return markdownToPango(release.Body, releasePageUrl(release))
+}
+
+func releasePageUrl(release *GithubRelease) string {
+	if release.HtmlUrl != "" {
+		return release.HtmlUrl
 	}
-	return body
+	if src, err := releaseSource(); err == nil {
+		return src.ReleasesPage()
+	}
+	return ""
 }
 
 func formatDate(iso string) string {
@@ -189,309 +219,228 @@ func formatDate(iso string) string {
 
 // --- UTILITÁRIOS GERAIS (TERMINAL E ZENITY) ---
 
-func getTerminal() (string, string) {
-	terms :=[]struct {
-		cmd string
-		arg string
-	}{
-		{"gnome-terminal", "--"},
-		{"konsole", "-e"},
-		{"xfce4-terminal", "-e"},
-		{"mate-terminal", "-e"},
-		{"alacritty", "-e"},
-		{"kitty", "-e"},
-		{"xterm", "-e"},
-		{"tilix", "-e"},
-		{"ashyterm", "-e"},
-		{"zashterminal", "-e"},
-		{"terminator", "-x"},
-	}
-
-	for _, t := range terms {
-		if _, err := exec.LookPath(t.cmd); err == nil {
-			return t.cmd, t.arg
-		}
-	}
-	return "", ""
-}
-
 func ensureZenity(d DistroInfo) {
 	if _, err := exec.LookPath("zenity"); err == nil {
 		return
 	}
 
 	var installCmd string
-	switch {
-	case strings.Contains(d.ID, "arch") || strings.Contains(d.IDLike, "arch") || strings.Contains(d.ID, "cachyos"):
-		installCmd = "sudo pacman -S --noconfirm zenity"
-	case strings.Contains(d.ID, "debian") || strings.Contains(d.IDLike, "debian") || strings.Contains(d.ID, "ubuntu"):
-		installCmd = "sudo apt-get update && sudo apt-get install -y zenity"
-	case strings.Contains(d.ID, "fedora") || strings.Contains(d.IDLike, "fedora"):
-		installCmd = "sudo dnf install -y zenity"
-	case strings.Contains(d.ID, "suse") || strings.Contains(d.IDLike, "suse"):
-		installCmd = "sudo zypper --non-interactive install -y zenity"
+	if b := detectNativeBackend(d); b != nil {
+		switch b.Manager {
+		case "pacman":
+			installCmd = "sudo pacman -S --noconfirm zenity"
+		case "apt":
+			installCmd = "sudo apt-get update && sudo apt-get install -y zenity"
+		case "dnf":
+			installCmd = "sudo dnf install -y zenity"
+		case "zypper":
+			installCmd = "sudo zypper --non-interactive install -y zenity"
+		case "eopkg":
+			installCmd = "sudo eopkg install -y zenity"
+		case "xbps":
+			installCmd = "sudo xbps-install -Sy zenity"
+		case "apk":
+			installCmd = "sudo apk add zenity"
+		case "emerge":
+			installCmd = "sudo emerge --ask=n gnome-extra/zenity"
+		case "nix":
+			installCmd = "nix-env -iA nixpkgs.zenity || nix-env -iA nixos.zenity"
+		}
 	}
 
 	if installCmd == "" {
-		fmt.Println("Erro: Zenity não encontrado e distribuição desconhecida para instalação automática.")
-		os.Exit(1)
+		consoleError(tr("Erro: Zenity não encontrado e distribuição desconhecida para instalação automática."))
+		exit(1)
 	}
 
-	termCmd, termArg := getTerminal()
-	if termCmd == "" {
-		fmt.Println("Erro: Zenity não encontrado e nenhum terminal detectado para realizar a instalação.")
-		os.Exit(1)
+	if findTerminal() == nil {
+		consoleError(tr("Erro: Zenity não encontrado e nenhum terminal detectado para realizar a instalação."))
+		exit(1)
 	}
 
-	tmpScript := filepath.Join(os.TempDir(), "install_zenity_dependency.sh")
+	tmpScript, err := tempPath("install_zenity_dependency.sh")
+	if err != nil {
+		consoleError(trf("Erro ao criar diretório temporário: %v", err))
+		exit(1)
+	}
+	// Os textos traduzidos entram no script já entre aspas simples
+	say := func(text string) string { return "echo " + shellQuote(text) }
 	scriptContent := fmt.Sprintf(`#!/bin/bash
 echo "=========================================="
-echo " O instalador gráfico requer o 'zenity'   "
+%s
 echo "=========================================="
 echo ""
-echo "O Zenity não foi encontrado no seu sistema."
-echo "Tentando instalar automaticamente..."
-echo "Comando: %s"
+%s
+%s
+%s
 echo ""
 %s
 
 EXIT_CODE=$?
 echo ""
 if [ $EXIT_CODE -eq 0 ]; then
-    echo "Sucesso! O Zenity foi instalado."
-    echo "O instalador continuará em breve..."
+    %s
+    %s
     sleep 2
 else
-    echo "Falha na instalação."
-    echo "Pressione ENTER para sair."
+    %s
+    %s
     read
 fi
 exit $EXIT_CODE
-`, installCmd, installCmd)
+`,
+		say(tr(" O instalador gráfico requer o 'zenity'")),
+		say(tr("O Zenity não foi encontrado no seu sistema.")),
+		say(tr("Tentando instalar automaticamente...")),
+		say(trf("Comando: %s", installCmd)),
+		installCmd,
+		say(tr("Sucesso! O Zenity foi instalado.")),
+		say(tr("O instalador continuará em breve...")),
+		say(tr("Falha na instalação.")),
+		say(tr("Pressione ENTER para sair.")))
 
 	if err := os.WriteFile(tmpScript,[]byte(scriptContent), 0755); err != nil {
-		fmt.Println("Erro ao criar script de instalação do Zenity:", err)
-		os.Exit(1)
+		consoleError(trf("Erro ao criar script de instalação do Zenity: %v", err))
+		exit(1)
 	}
 	defer os.Remove(tmpScript)
 
-	cmd := exec.Command(termCmd, termArg, tmpScript)
-	cmd.Run()
-
-	if _, err := exec.LookPath("zenity"); err != nil {
-		fmt.Println("Zenity ainda não foi encontrado. A instalação falhou ou foi cancelada.")
-		os.Exit(1)
-	}
-}
-
-// --- FUNÇÕES AUR ---
-
-func installViaAUR(distro DistroInfo, version string) {
-	msg := fmt.Sprintf(
-		"Sistema <b>Arch Linux</b> detectado.\n\n"+
-			"O <b>%s</b> será instalado diretamente do <b>AUR</b> para resolver as dependências automaticamente.\n\n"+
-			"Isso abrirá um terminal para compilação.\n"+
-			"Deseja continuar?", AppPrettyName)
-
-	if !zenityQuestion(msg) {
-		os.Exit(0)
+	code, err := runInTerminal("bash", tmpScript)
+	if err != nil {
+		consoleError(trf("Erro ao executar a instalação do Zenity no terminal: %v", err))
+		exit(1)
 	}
-
-	termCmd, termArg := getTerminal()
-	if termCmd == "" {
-		zenityError("Nenhum terminal compatível encontrado para executar a instalação do AUR.")
-		os.Exit(1)
+	if code != 0 {
+		consoleError(trf("A instalação do Zenity terminou com erro (código %d).", code))
+		exit(1)
 	}
 
-	tmpScript := filepath.Join(os.TempDir(), "install_tac_aur.sh")
-
-	scriptContent := fmt.Sprintf(`#!/bin/bash
-echo "=== INSTALAÇÃO VIA AUR: %s ==="
-echo ""
-
-check_install() {
-    if pacman -Qi %s &> /dev/null; then
-        echo ""
-        echo ">>> SUCESSO! Pacote instalado."
-        echo "Pressione ENTER para fechar."
-        read
-        exit 0
-    else
-        echo ""
-        echo ">>> FALHA NA INSTALAÇÃO."
-        echo "Pressione ENTER para fechar."
-        read
-        exit 1
-    fi
-}
-
-# 1. Tenta usar YAY
-if command -v yay &> /dev/null; then
-    echo ">> Usando YAY..."
-    rm -rf "$HOME/.cache/yay/%s"
-    yay -S --noconfirm %s
-    check_install
-
-# 2. Tenta usar PARU
-elif command -v paru &> /dev/null; then
-    echo ">> Usando PARU..."
-    rm -rf "$HOME/.cache/paru/clone/%s"
-    rm -rf "$HOME/.cache/paru/%s"
-    paru -S --rebuild --noconfirm %s
-    check_install
-
-# 3. Fallback: Manual
-else
-    echo ">> Nenhum helper (yay/paru) encontrado. Instalando manualmente..."
-    echo ">> Instalando base-devel e git..."
-    sudo pacman -S --needed --noconfirm base-devel git
-    
-    BUILD_DIR="/tmp/%s-aur-build"
-    rm -rf "$BUILD_DIR"
-    mkdir -p "$BUILD_DIR"
-    cd "$BUILD_DIR" || exit 1
-    
-    echo ">> Clonando AUR..."
-    git clone "https://aur.archlinux.org/%s.git"
-    cd "%s" || exit 1
-    
-    echo ">> Compilando..."
-    makepkg -si --noconfirm
-    check_install
-fi
-`, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName, AppName)
-
-	if err := os.WriteFile(tmpScript,[]byte(scriptContent), 0755); err != nil {
-		zenityError("Erro ao criar script temporário: " + err.Error())
-		os.Exit(1)
-	}
-
-	cmd := exec.Command(termCmd, termArg, tmpScript)
-	if err := cmd.Run(); err != nil {
-		zenityError("Erro ao abrir o terminal: " + err.Error())
-	} else {
-		if checkIsInstalled() {
-			writeInstalledVersion(version)
-			if zenityQuestionCustomTitle("Instalação do AUR finalizada.\nDeseja abrir agora?", "Sucesso") {
-				openApplication()
-			}
-		}
+	if _, err := exec.LookPath("zenity"); err != nil {
+		consoleError(tr("Zenity ainda não foi encontrado. A instalação falhou ou foi cancelada."))
+		exit(1)
 	}
-	os.Remove(tmpScript)
-	os.Exit(0)
 }
 
 // --- DESINSTALAÇÃO ---
 
-func getUninstallCmd(distro DistroInfo) string {
-	switch {
-	case strings.Contains(distro.ID, "arch") || strings.Contains(distro.IDLike, "arch") ||
-		strings.Contains(distro.ID, "cachyos") || strings.Contains(distro.ID, "manjaro") ||
-		strings.Contains(distro.IDLike, "manjaro"):
-		return "pacman -Rns --noconfirm " + AppName
-	case strings.Contains(distro.ID, "debian") || strings.Contains(distro.IDLike, "debian") ||
-		strings.Contains(distro.ID, "ubuntu"):
-		return "apt remove -y " + AppName
-	case strings.Contains(distro.ID, "fedora") || strings.Contains(distro.IDLike, "fedora"):
-		return "dnf remove -y " + AppName
-	case strings.Contains(distro.ID, "suse") || strings.Contains(distro.IDLike, "suse"):
-		return "zypper --non-interactive remove -y " + AppName
+func getUninstallCmd(distro DistroInfo) ([]string, bool) {
+	b := detectNativeBackend(distro)
+	if b == nil {
+		return nil, false
 	}
-	return ""
+	return b.RemoveArgs, b.NeedsRoot
 }
 
-func uninstallPackage(distro DistroInfo) bool {
+func uninstallPackage(ctx context.Context, distro DistroInfo) bool {
 	uninstalledAny := false
 
+	// Tenta remover o AppImage (se existir)
+	if appImageInstalled() && uninstallAppImage() {
+		uninstalledAny = true
+	}
+
+	// Tenta remover a instalação local (se existir)
+	if localInstalled() && uninstallUserLocal() {
+		uninstalledAny = true
+	}
+
 	// Tenta remover o Flatpak (se existir)
-	if err := exec.Command("flatpak", "info", FlatpakID).Run(); err == nil {
-		exec.Command("flatpak", "uninstall", "-y", FlatpakID).Run()
+	if uninstallFlatpak() {
 		uninstalledAny = true
 	}
 
 	// Tenta remover pacote Nativo
-	cmd := getUninstallCmd(distro)
-	if cmd != "" {
-		fullCmd := fmt.Sprintf("pkexec %s", cmd)
-		if exec.Command("bash", "-c", fullCmd).Run() == nil {
+	cmd, needsRoot := getUninstallCmd(distro)
+	if len(cmd) > 0 && nativeInstalled() {
+		err := runnerFor(needsRoot).Run(ctx, io.Discard, cmd[0], cmd[1:]...)
+		if err == nil {
 			uninstalledAny = true
+		} else if errors.Is(err, errNoPrivilege) {
+			zenityError(escapeMarkup(err.Error()))
 		}
 	}
 
 	return uninstalledAny
 }
 
-func handleUninstall(distro DistroInfo) {
+func handleUninstall(ctx context.Context, distro DistroInfo) {
 	if !zenityQuestionCustomTitle(
-		"Tem certeza que deseja desinstalar o <b>"+AppPrettyName+"</b>?\n\nO aplicativo será removido do sistema.",
-		"Confirmar desinstalação",
+		trf("Tem certeza que deseja desinstalar o <b>%s</b>?\n\nO aplicativo será removido do sistema.", AppPrettyName),
+		tr("Confirmar desinstalação"),
 	) {
 		return
 	}
 
-	if uninstallPackage(distro) {
-		removeVersionFile()
-		zenityInfo("O <b>" + AppPrettyName + "</b> foi desinstalado com sucesso.")
+	format, from := installedFormat(), previousVersion()
+	lastDownload.Name, lastDownload.Checksum = "", ""
+	if uninstallPackage(ctx, distro) {
+		appendHistory(HistoryEntry{Action: ActionUninstall, Format: format, FromVersion: from, Result: ResultOk})
+		clearInstallRecord()
+		zenityInfo(trf("O <b>%s</b> foi desinstalado com sucesso.", AppPrettyName))
 	} else {
-		zenityError("Falha na desinstalação ou operação cancelada pelo usuário.")
+		appendHistory(HistoryEntry{Action: ActionUninstall, Format: format, FromVersion: from, Result: ResultFailed})
+		zenityError(tr("Falha na desinstalação ou operação cancelada pelo usuário."))
 	}
 }
 
 // --- FUNÇÃO PARA ESCOLHA DO FORMATO DE INSTALAÇÃO ---
 
 func chooseInstallFormat() string {
-	msg := "<b>Como você prefere instalar o pacote?</b>\n\n" +
-		"<b>• Nativo:</b> Recomendado (.deb, .rpm, AUR). Melhor integração.\n" +
-		"<b>• Flatpak:</b> Universal. Roda isolado em Sandbox e não afeta o sistema base."
-
-	choice := zenityTripleChoice(msg, "Formato de Instalação", "Nativo", "Flatpak", "Cancelar")
-	switch choice {
-	case "ok":
-		return "Nativo"
-	case "extra":
-		return "Flatpak"
-	default:
-		return ""
-	}
+	msg := tr("<b>Como você prefere instalar o pacote?</b>")
+
+	return zenityRadioList(msg, tr("Formato de Instalação"), [][3]string{
+		{FormatNative, tr("Nativo"), tr("Recomendado (.deb, .rpm, AUR). Melhor integração.")},
+		{FormatFlatpak, FormatFlatpak, tr("Universal. Roda isolado em Sandbox e não afeta o sistema base.")},
+		{FormatAppImage, FormatAppImage, tr("Arquivo único em ~/Applications. Ideal para distribuições não suportadas.")},
+		{FormatLocal, tr("Local"), tr("Sem root. Instala o código-fonte em ~/.local/share com um ambiente Python próprio.")},
+	})
 }
 
 // --- MAIN ---
 
 func main() {
+	ctx := setupSignalCleanup()
+	defer cleanupTemp()
+
+	args, verbose := parseGlobalFlags(os.Args[1:])
+	// O auxiliar roda como root e não grava no log do usuário
+	if len(args) > 0 && args[0] == HelperCommand {
+		exit(runPrivilegedHelper())
+	}
+	setupLogging(verbose)
+
+	runSubcommand(args)
+
 	distro := getDistroInfo()
 
 	ensureZenity(distro)
 
+	// Novidades desde a versão instalada, mostradas de novo na confirmação
+	updateNews := ""
 	if checkIsInstalled() {
-		release, err := getLatestRelease(GithubUser, AppName)
+		release, err := getLatestRelease(ctx)
 
 		if err != nil {
 			choice := zenityTripleChoice(
-				"O <b>"+AppPrettyName+"</b> está instalado.\n\nNão foi possível verificar atualizações:\n<small>"+err.Error()+"</small>",
+				trf("O <b>%s</b> está instalado.\n\nNão foi possível verificar atualizações:\n<small>%s</small>", AppPrettyName, err.Error()),
 				AppPrettyName,
-				"Abrir", "Desinstalar", "Fechar",
+				tr("Abrir"), tr("Desinstalar"), tr("Fechar"),
 			)
 			switch choice {
 			case "ok":
 				openApplication()
 			case "extra":
-				handleUninstall(distro)
+				handleUninstall(ctx, distro)
 			}
-			os.Exit(0)
+			exit(0)
 		}
 
 		latest := strings.TrimPrefix(release.TagName, "v")
 		installed, verErr := getInstalledVersion()
 
-		if (installed == "" || verErr != nil) && strings.Contains(distro.ID, "arch") {
-			out, _ := exec.Command("pacman", "-Q", AppName).Output()
-			parts := strings.Fields(string(out))
-			if len(parts) >= 2 {
-				installed = parts [1]
-				// Remove a "epoch" (ex: "1:1.3.1.4-1" vira "1.3.1.4-1")
-				if idx := strings.Index(installed, ":"); idx != -1 {
-					installed = installed[idx+1:]
-				}
+		if installed == "" || verErr != nil {
+			if v := queryNativeVersion(distro); v != "" {
+				installed = v
 				verErr = nil
 			}
 		}
@@ -502,22 +451,32 @@ func main() {
 		}
 
 		if needsUpdate {
+			var fullNews string
+			updateNews, fullNews = updateNotes(ctx, release, installed)
 			if installed == "" {
-				installed = "(desconhecida)"
+				installed = tr("(desconhecida)")
 			}
-			msg := fmt.Sprintf(
+			msg := trf(
 				"Atualização disponível!\n\n<b>Versão instalada</b>: %s\n<b>Versão nova</b>: %s",
 				installed, latest,
 			)
-			choice := zenityTripleChoice(msg, AppPrettyName, "Atualizar", "Desinstalar", "Fechar")
-			switch choice {
-			case "ok":
-				goto INSTALL_FLOW
-			case "extra":
-				handleUninstall(distro)
-				os.Exit(0)
-			default:
-				os.Exit(0)
+			msg += "\n\n" + tr("<b>Novidades:</b>") + "\n<span size='small'>" + updateNews + "</span>"
+			extras := []string{tr("Desinstalar")}
+			if fullNews != "" {
+				extras = append(extras, tr("Ver todas as novidades"))
+			}
+			for {
+				switch zenityChoice(msg, AppPrettyName, tr("Atualizar"), tr("Fechar"), extras...) {
+				case "ok":
+					goto INSTALL_FLOW
+				case tr("Desinstalar"):
+					handleUninstall(ctx, distro)
+					exit(0)
+				case tr("Ver todas as novidades"):
+					showChangelog(fullNews)
+				default:
+					exit(0)
+				}
 			}
 		}
 
@@ -525,119 +484,201 @@ func main() {
 		if displayVersion == "" {
 			displayVersion = latest
 		}
-		choice := zenityTripleChoice(
-			"O <b>"+AppPrettyName+"</b> já está instalado e atualizado.\n\n<b>Versão</b>: "+displayVersion,
+		var extras []string
+		if flatpakInstalled() {
+			extras = append(extras, tr("Permissões"))
+		}
+		extras = append(extras, tr("Histórico"), tr("Desinstalar"))
+		choice := zenityChoice(
+			trf("O <b>%s</b> já está instalado e atualizado.\n\n<b>Versão</b>: %s", AppPrettyName, displayVersion),
 			AppPrettyName,
-			"Abrir", "Desinstalar", "Fechar",
+			tr("Abrir"), tr("Fechar"), extras...,
 		)
 		switch choice {
 		case "ok":
 			openApplication()
-		case "extra":
-			handleUninstall(distro)
+		case tr("Permissões"):
+			handlePermissions()
+		case tr("Histórico"):
+			handleHistory(ctx, distro)
+		case tr("Desinstalar"):
+			handleUninstall(ctx, distro)
 		}
-		os.Exit(0)
+		exit(0)
 	}
 
 INSTALL_FLOW:
 
-	release, err := getLatestRelease(GithubUser, AppName)
+	release, err := getLatestRelease(ctx)
 	if err != nil {
-		zenityError("Erro ao consultar GitHub:\n" + err.Error())
-		os.Exit(1)
+		showErrorOrCancelled(tr("Erro ao consultar as releases:\n"), err)
+		exit(1)
 	}
 
 	version := strings.TrimPrefix(release.TagName, "v")
 	date := formatDate(release.PublishedAt)
-	news := formatReleaseNotes(release.Body)
+	news := updateNews
+	if news == "" {
+		news = formatReleaseNotes(release)
+	}
 
-	msg := fmt.Sprintf(
+	msg := trf(
 		"<b>%s</b> será instalado no seu computador.\n\n<b>Versão</b>: %s\n<b>Lançamento</b>: %s\n<b>Sistema</b>: %s\n\n<b>Novidades:</b>\n<span size='small'>%s</span>\n\nDeseja continuar?",
 		AppPrettyName, version, date, distro.Pretty, news,
 	)
 
 	if !zenityQuestion(msg) {
-		os.Exit(0)
+		exit(0)
 	}
 
-	// Solicita o formato de instalação para o usuário
-	formatChoice := chooseInstallFormat()
+	// Em atualizações mantém o formato já instalado; senão pergunta ao usuário
+	formatChoice := ""
+	action, fromVersion := ActionInstall, ""
+	if checkIsInstalled() {
+		formatChoice = installedFormat()
+		action, fromVersion = ActionUpdate, previousVersion()
+	}
 	if formatChoice == "" {
-		os.Exit(0)
-	}
-
-	var suffix, installCmd string
-	var needsRoot bool
-
-	if formatChoice == "Flatpak" {
-		if _, err := exec.LookPath("flatpak"); err != nil {
-			zenityError("O comando 'flatpak' não foi encontrado. Por favor, instale o suporte a Flatpak na sua distribuição para continuar.")
-			os.Exit(1)
-		}
-		
-		// --- A MÁGICA ENTRA AQUI ---
-		// Garante que o repositório do Flathub exista para o usuário antes de instalar
-		// Assim ele sabe de onde baixar o org.gnome.Platform automaticamente
-		exec.Command("flatpak", "remote-add", "--user", "--if-not-exists", "flathub", "https://dl.flathub.org/repo/flathub.flatpakrepo").Run()
-		
-		suffix = ".flatpak"
-		installCmd = "flatpak install --user -y"
-		needsRoot = false
-	} else {
-		// Logica para formato Nativo
-		needsRoot = true
-		switch {
-		case strings.Contains(distro.ID, "arch") || strings.Contains(distro.IDLike, "arch") ||
-			strings.Contains(distro.IDLike, "manjaro") || strings.Contains(distro.ID, "manjaro") ||
-			strings.Contains(distro.ID, "cachyos"):
-			installViaAUR(distro, version)
+		formatChoice = chooseInstallFormat()
+	}
+	if formatChoice == "" {
+		exit(0)
+	}
+
+	var backend *NativeBackend
+
+	if formatChoice == FormatNative {
+		backend = detectNativeBackend(distro)
+		if backend == nil {
+			zenityError(tr("Distribuição não suportada para o modo Nativo. Tente via Flatpak ou AppImage."))
+			exit(1)
+		}
+
+		// Sem pacote pré-compilado na release, o Arch compila a partir do AUR
+		if backend.Manager == "pacman" && !hasAsset(release, backend.Suffix) {
+			installViaAUR(ctx, distro, action, fromVersion, version)
 			return
+		}
 
-		case strings.Contains(distro.ID, "debian") || strings.Contains(distro.IDLike, "debian") ||
-			strings.Contains(distro.ID, "ubuntu"):
-			suffix = ".deb"
-			installCmd = "apt install -y"
-
-		case strings.Contains(distro.ID, "fedora") || strings.Contains(distro.IDLike, "fedora") ||
-			strings.Contains(distro.IDLike, "bazzite") || strings.Contains(distro.ID, "bazzite") ||
-			strings.Contains(distro.ID, "suse") || strings.Contains(distro.IDLike, "suse"):
-			suffix = ".rpm"
-			installCmd = "dnf install -y"
-
-			if strings.Contains(distro.ID, "suse") || strings.Contains(distro.IDLike, "suse") {
-				cmdDeps := fmt.Sprintf("pkexec zypper --non-interactive install -y %s", SuseDeps)
-				errDeps := exec.Command("bash", "-c", cmdDeps).Run()
-				if errDeps != nil {
-					fmt.Println("Aviso: Falha ao instalar dependências do SUSE ou cancelado pelo usuário.")
-				}
-				installCmd = "zypper --non-interactive install -y --allow-unsigned-rpm"
+		// Sem pacote nativo nesta release: oferece o Flatpak ou a instalação local
+		if backend.Suffix == "" || !hasAsset(release, backend.Suffix) {
+			msg := trf(
+				"Esta versão do <b>%s</b> não possui pacote nativo para <b>%s</b> (%s).\n\n"+
+					"Deseja instalar via <b>Flatpak</b> ou como instalação <b>Local</b> (sem root)?",
+				AppPrettyName, backend.Pretty, backend.Manager)
+			switch zenityTripleChoice(msg, tr("Formato de Instalação"), FormatFlatpak, tr("Local"), tr("Cancelar")) {
+			case "ok":
+				formatChoice = FormatFlatpak
+			case "extra":
+				formatChoice = FormatLocal
+			default:
+				exit(0)
 			}
+		}
+	}
+
+	if formatChoice != FormatNative {
+		var ok bool
+		switch formatChoice {
+		case FormatFlatpak:
+			ok = installFlatpak(ctx, release, version)
+		case FormatAppImage:
+			ok = installAppImage(ctx, release, version)
+		case FormatLocal:
+			ok = installUserLocal(ctx, release, version)
+		}
+		var result error
+		if !ok {
+			result = errors.New(tr("falha na instalação"))
+		}
+		recordHistory(action, formatChoice, fromVersion, version, result)
+		if ok && zenityQuestionCustomTitle(tr("Instalação concluída!\nDeseja abrir agora?"), tr("Sucesso")) {
+			openApplication()
+		}
+		return
+	}
+
+	// Logica para formato Nativo
+	// Falhas antes da instalação também ficam registradas no histórico
+	fail := func(err error) {
+		recordHistory(action, formatChoice, fromVersion, version, err)
+		exit(1)
+	}
+
+	needsRoot := backend.NeedsRoot
+	if needsRoot {
+		if _, err := detectPrivilege(); err != nil {
+			zenityError(escapeMarkup(err.Error()))
+			fail(err)
+		}
+	}
 
-		default:
-			zenityError("Distribuição não suportada para o modo Nativo. Tente via Flatpak.")
-			os.Exit(1)
+	if backend.Manager == "zypper" {
+		args := suseDepsArgs()
+		errDeps := runnerFor(true).Run(ctx, io.Discard, args[0], args[1:]...)
+		if isCancelled(errDeps) {
+			showCancelled()
+			fail(errDeps)
+		}
+		if errDeps != nil {
+			slog.Warn("falha ao instalar as dependências do SUSE", "erro", errDeps)
 		}
 	}
 
-	fileName, url, err := findAssetUrl(release, suffix)
+	fileName, url, err := findAssetUrl(release, backend.Suffix)
 	if err != nil {
 		zenityError(err.Error())
-		os.Exit(1)
+		fail(err)
 	}
 
-	tmp := filepath.Join(os.TempDir(), fileName)
-	if err := downloadFile(url, tmp); err != nil {
-		zenityError("Erro no download:\n" + err.Error())
-		os.Exit(1)
+	tmp, err := tempPath(fileName)
+	if err != nil {
+		zenityError(trf("Erro ao criar diretório temporário:\n%v", err))
+		fail(err)
+	}
+	if err := downloadFile(ctx, url, tmp); err != nil {
+		showErrorOrCancelled(tr("Erro no download:\n"), err)
+		fail(err)
+	}
+
+	// O arquivo será lido pelo root: confere que ninguém o trocou
+	if err := verifyPrivate(tmp); err != nil {
+		zenityError(trf("Arquivo baixado inseguro, instalação abortada:\n%s", escapeMarkup(err.Error())))
+		fail(err)
+	}
+
+	// Guarda o pacote para permitir voltar a esta versão depois
+	cached, cacheErr := cachePackage(tmp)
+	if cacheErr != nil {
+		slog.Warn("falha ao guardar o pacote no cache", "erro", cacheErr)
+	}
+
+	installArgs, cleanup, err := backend.installArgs(tmp)
+	if err != nil {
+		zenityError(trf("Erro ao preparar o pacote:\n%v", err))
+		fail(err)
+	}
+	defer cleanup()
+
+	err = installPackage(ctx, installArgs, needsRoot)
+	recordHistory(action, formatChoice, fromVersion, version, err)
+	if err == nil {
+		if cached != "" {
+			pruneAfterInstall(filepath.Base(cached))
+		}
+	} else if cached != "" {
+		os.Remove(cached)
 	}
 
-	if installPackage(installCmd, tmp, needsRoot) {
-		writeInstalledVersion(version)
-		if zenityQuestionCustomTitle("Instalação concluída!\nDeseja abrir agora?", "Sucesso") {
+	if err == nil {
+		recordInstall(formatChoice, version)
+		if zenityQuestionCustomTitle(tr("Instalação concluída!\nDeseja abrir agora?"), tr("Sucesso")) {
 			openApplication()
 		}
+	} else if isCancelled(err) {
+		showCancelled()
 	} else {
-		zenityError("Falha na instalação ou a operação foi cancelada.")
+		zenityError(tr("Falha na instalação."))
 	}
 
 	os.Remove(tmp)
@@ -664,66 +705,153 @@ func getDistroInfo() DistroInfo {
 	return info
 }
 
-func downloadFile(url, path string) error {
-	cmd := fmt.Sprintf(
-		"wget -O '%s' '%s' 2>&1 | zenity --progress --pulsate --title='Baixando...' --auto-close",
-		path, url,
-	)
-	return exec.Command("bash", "-c", cmd).Run()
+// Os pacotes são instalados como root e não têm assinatura conferida, então
+// só são baixados por https, inclusive depois de redirecionamentos
+func httpsOnly(req *http.Request, via []*http.Request) error {
+	if req.URL.Scheme != "https" {
+		return fmt.Errorf(tr("redirecionamento recusado, não usa https://: %s"), req.URL)
+	}
+	if len(via) >= 10 {
+		return errors.New(tr("redirecionamentos demais"))
+	}
+	return nil
 }
 
-func installPackage(cmd, file string, needsRoot bool) bool {
-	var c string
-	if needsRoot {
-		c = fmt.Sprintf("pkexec %s '%s'", cmd, file)
+// Cancelar (botão da janela ou ctx) interrompe o download e apaga o arquivo parcial
+func downloadFile(ctx context.Context, url, path string) error {
+	if !strings.HasPrefix(url, "https://") {
+		return fmt.Errorf(tr("download recusado, não usa https://: %s"), url)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	update, done := startProgress(tr("Baixando..."), trf("Baixando %s...", filepath.Base(path)), cancel)
+	defer done()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	slog.Info("download", "url", url, "destino", path)
+	client := &http.Client{CheckRedirect: httpsOnly}
+	resp, err := client.Do(req)
+	if err != nil {
+		slog.Error("falha no download", "url", url, "erro", err)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		slog.Error("falha no download", "url", url, "status", resp.StatusCode)
+		return fmt.Errorf(tr("o servidor retornou erro %d"), resp.StatusCode)
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	pw := &progressWriter{total: resp.ContentLength, update: update}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(out, pw, hash), resp.Body)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		slog.Error("download interrompido", "url", url, "erro", err)
+		os.Remove(path)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+
+	lastDownload.Name = strings.TrimSuffix(filepath.Base(path), ".part")
+	lastDownload.Checksum = hex.EncodeToString(hash.Sum(nil))
+	slog.Info("download concluído", "arquivo", lastDownload.Name, "sha256", lastDownload.Checksum)
+	return nil
+}
+
+// Converte bytes baixados em porcentagem para a janela de progresso
+type progressWriter struct {
+	total   int64
+	written int64
+	last    int
+	update  func(pct int, msg string)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.written += int64(len(b))
+	if p.total > 0 {
+		pct := int(p.written * 100 / p.total)
+		if pct != p.last {
+			p.last = pct
+			p.update(pct, trf("%.1f de %.1f MB", float64(p.written)/1e6, float64(p.total)/1e6))
+		}
+	}
+	return len(b), nil
+}
+
+// Devolve context.Canceled se o usuário cancelar antes do gerenciador de
+// pacotes começar; uma transação já iniciada como root vai até o fim
+func installPackage(ctx context.Context, args []string, needsRoot bool) error {
+	title := tr("Instalando...")
+	text := trf("Instalando o %s...\n\nPor favor, aguarde. O processo está em andamento e pode levar alguns minutos caso seja necessário baixar dependências.", AppPrettyName)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	waiting := make(chan func(), 1)
+	onCancel := func() {
+		cancel()
+		if needsRoot {
+			waiting <- startPulsate(tr("Cancelando..."), tr("Aguardando o gerenciador de pacotes concluir a operação atual...\n\nInterrompê-lo agora poderia deixar o sistema inconsistente."))
+		}
+	}
+
+	// --- MÁGICA DA UX: A janela mostra cada linha do gerenciador de pacotes ---
+	parse := progressParserFor(args[0])
+	var update func(pct int, msg string)
+	var done func()
+	if parse != nil {
+		update, done = startProgress(title, text, onCancel)
 	} else {
-		c = fmt.Sprintf("%s '%s'", cmd, file)
+		update, done = startPulsateProgress(title, text, onCancel)
 	}
-	
-	// --- MÁGICA DA UX: Abre a janela de carregamento em segundo plano ---
-	zenityCmd := exec.Command("zenity", "--progress", "--pulsate", 
-		"--title=Instalando...", 
-		"--text=Instalando o Tac Writer...\n\nPor favor, aguarde. O processo está em andamento e pode levar alguns minutos caso seja necessário baixar dependências.", 
-		"--auto-close", "--no-cancel", "--width=450")
-	
-	// Mantemos o canal de entrada aberto para a janela não fechar sozinha
-	zenityStdin, _ := zenityCmd.StdinPipe()
-	zenityCmd.Start()
+
+	out := &lineWriter{onLine: func(line string) {
+		pct, msg := -1, line
+		if parse != nil {
+			pct, msg = parse(line)
+		}
+		update(pct, msg)
+	}}
 
 	// --- EXECUTA A INSTALAÇÃO REAL AQUI ---
-	out, err := exec.Command("bash", "-c", c).CombinedOutput()
+	err := runnerFor(needsRoot).Run(ctx, out, args[0], args[1:]...)
+	out.Flush()
 
 	// --- FECHA A JANELA DE CARREGAMENTO ---
-	if zenityStdin != nil {
-		zenityStdin.Close() // Manda sinal para o zenity parar
-	}
-	if zenityCmd.Process != nil {
-		zenityCmd.Process.Kill() // Garante que a janela suma da tela imediatamente
+	done()
+	select {
+	case stop := <-waiting:
+		stop()
+	default:
 	}
 
 	// --- TRATAMENTO DE ERROS ---
-	if err != nil {
-		errMsg := strings.TrimSpace(string(out))
-		if errMsg == "" {
-			errMsg = err.Error()
-		}
-		
-		errMsg = strings.ReplaceAll(errMsg, "<", "&lt;")
-		errMsg = strings.ReplaceAll(errMsg, ">", "&gt;")
-		
-		textoErro := fmt.Sprintf("<b>Erro detalhado retornado pelo sistema:</b>\n\n<span size='small'>%s</span>", errMsg)
-		exec.Command("zenity", "--error", "--title=Erro de Instalação", "--text="+textoErro, "--width=650").Run()
-		
-		return false
+	if err != nil && !isCancelled(err) {
+		showCommandError(tr("Erro de Instalação"), out.Bytes(), err)
 	}
-	
-	return true
+	return err
 }
 
 // --- ZENITY HELPERS ---
 
 func zenityQuestion(text string) bool {
-	return zenityQuestionCustomTitle(text, "Instalador do "+AppPrettyName)
+	return zenityQuestionCustomTitle(text, trf("Instalador do %s", AppPrettyName))
 }
 
 func zenityQuestionCustomTitle(text, title string) bool {
@@ -731,28 +859,191 @@ func zenityQuestionCustomTitle(text, title string) bool {
 }
 
 func zenityError(text string) {
-	exec.Command("zenity", "--error", "--text="+text, "--width=400").Run()
+	rememberError(text)
+	showErrorDialog("--text="+text, "--width=400")
+}
+
+// Diálogo de erro com o botão para salvar o relatório de diagnóstico
+func showErrorDialog(args ...string) {
+	args = append([]string{"--error", "--extra-button=" + reportButton}, args...)
+	out, _ := exec.Command("zenity", args...).Output()
+	if strings.TrimSpace(string(out)) == reportButton {
+		saveReportDialog()
+	}
 }
 
 func zenityInfo(text string) {
 	exec.Command("zenity", "--info", "--text="+text, "--width=400").Run()
 }
 
+// Lista de opções (valor, rótulo, descrição) com seleção única; retorna o
+// valor escolhido ou "" se cancelado
+func zenityRadioList(text, title string, options [][3]string) string {
+	args := []string{"--list", "--radiolist",
+		"--title=" + title,
+		"--text=" + text,
+		"--column=", "--column=", "--column=" + tr("Opção"), "--column=" + tr("Descrição"),
+		"--print-column=2", "--hide-column=2",
+		"--width=650", "--height=300",
+	}
+	for i, opt := range options {
+		selected := "FALSE"
+		if i == 0 {
+			selected = "TRUE"
+		}
+		args = append(args, selected, opt[0], opt[1], opt[2])
+	}
+
+	out, err := exec.Command("zenity", args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Abre uma janela de progresso pulsante e retorna a função que a fecha
+func startPulsate(title, text string) func() {
+	zenityCmd := exec.Command("zenity", "--progress", "--pulsate", 
+		"--title="+title, 
+		"--text="+text, 
+		"--auto-close", "--no-cancel", "--width=450")
+	
+	// Mantemos o canal de entrada aberto para a janela não fechar sozinha
+	zenityStdin, _ := zenityCmd.StdinPipe()
+	zenityCmd.Start()
+
+	return func() {
+		if zenityStdin != nil {
+			zenityStdin.Close() // Manda sinal para o zenity parar
+		}
+		if zenityCmd.Process != nil {
+			zenityCmd.Process.Kill() // Garante que a janela suma da tela imediatamente
+		}
+	}
+}
+
+// Abre uma janela de progresso com porcentagem. update recebe -1 quando
+// só o texto muda e fica em 99% até done fechar a janela, senão o
+// --auto-close a fecharia antes do fim do comando.
+// onCancel é chamado se o usuário clicar em Cancelar; nil esconde o botão
+func startProgress(title, text string, onCancel func()) (update func(pct int, msg string), done func()) {
+	return startProgressDialog(title, text, false, onCancel)
+}
+
+// Como startPulsate, mas permite trocar o texto da janela
+func startPulsateProgress(title, text string, onCancel func()) (update func(pct int, msg string), done func()) {
+	return startProgressDialog(title, text, true, onCancel)
+}
+
+func startProgressDialog(title, text string, pulsate bool, onCancel func()) (update func(pct int, msg string), done func()) {
+	args := []string{"--progress",
+		"--title=" + title,
+		"--text=" + text,
+		"--auto-close", "--width=450"}
+	if pulsate {
+		args = append(args, "--pulsate")
+	}
+	if onCancel == nil {
+		args = append(args, "--no-cancel")
+	}
+	zenityCmd := exec.Command("zenity", args...)
+
+	zenityStdin, err := zenityCmd.StdinPipe()
+	if err != nil || zenityCmd.Start() != nil {
+		return func(int, string) {}, func() {}
+	}
+
+	// O zenity sai com erro quando o usuário clica em Cancelar
+	var mu sync.Mutex
+	closed := false
+	exited := make(chan struct{})
+	go func() {
+		err := zenityCmd.Wait()
+		mu.Lock()
+		cancelled := err != nil && !closed
+		closed = true
+		mu.Unlock()
+		if cancelled && onCancel != nil {
+			onCancel()
+		}
+		close(exited)
+	}()
+
+	update = func(pct int, msg string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		if pct >= 0 && !pulsate {
+			if pct > 99 {
+				pct = 99
+			}
+			fmt.Fprintf(zenityStdin, "%d\n", pct)
+		}
+		if msg != "" {
+			fmt.Fprintf(zenityStdin, "# %s\n", escapeMarkup(strings.ReplaceAll(msg, "\n", " ")))
+		}
+	}
+	done = func() {
+		mu.Lock()
+		closed = true
+		mu.Unlock()
+		zenityStdin.Close()
+		if zenityCmd.Process != nil {
+			zenityCmd.Process.Kill()
+		}
+		<-exited
+	}
+	return update, done
+}
+
+func escapeMarkup(s string) string {
+	s = strings.ReplaceAll(s, "&", "&amp;")
+	s = strings.ReplaceAll(s, "<", "&lt;")
+	return strings.ReplaceAll(s, ">", "&gt;")
+}
+
+// Mostra a saída de um comando que falhou
+func showCommandError(title string, out []byte, err error) {
+	errMsg := strings.TrimSpace(string(out))
+	if errMsg == "" {
+		errMsg = err.Error()
+	}
+
+	rememberError(title + ":\n" + errMsg)
+	textoErro := trf("<b>Erro detalhado retornado pelo sistema:</b>\n\n<span size='small'>%s</span>", escapeMarkup(errMsg))
+	showErrorDialog("--title="+title, "--text="+textoErro, "--width=650")
+}
+
 func zenityTripleChoice(text, title, okLabel, extraLabel, cancelLabel string) string {
-	cmd := exec.Command("zenity", "--question",
-		"--title="+title,
-		"--text="+text,
-		"--ok-label="+okLabel,
-		"--cancel-label="+cancelLabel,
-		"--extra-button="+extraLabel,
-		"--width=500",
-	)
+	choice := zenityChoice(text, title, okLabel, cancelLabel, extraLabel)
+	if choice == extraLabel {
+		return "extra"
+	}
+	return choice
+}
+
+// Pergunta com botões extras; retorna "ok", "cancel" ou o rótulo do botão extra
+func zenityChoice(text, title, okLabel, cancelLabel string, extraLabels ...string) string {
+	args := []string{"--question",
+		"--title=" + title,
+		"--text=" + text,
+		"--ok-label=" + okLabel,
+		"--cancel-label=" + cancelLabel,
+	}
+	for _, l := range extraLabels {
+		args = append(args, "--extra-button="+l)
+	}
+	args = append(args, "--width=500")
 
-	out, err := cmd.Output()
+	out, err := exec.Command("zenity", args...).Output()
 	output := strings.TrimSpace(string(out))
 
-	if output == extraLabel {
-		return "extra"
+	for _, l := range extraLabels {
+		if output == l {
+			return l
+		}
 	}
 
 	if err == nil {
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// --- DIRETÓRIO TEMPORÁRIO DA EXECUÇÃO ---
+
+// Todos os downloads e scripts ficam num diretório criado com os.MkdirTemp
+// (nome aleatório, permissão 0700), para que outro usuário local não consiga
+// criar antes um link simbólico com o mesmo nome.
+var (
+	workDirOnce sync.Once
+	workDir     string
+	workDirErr  error
+	cleanupMu   sync.Mutex
+)
+
+func getWorkDir() (string, error) {
+	workDirOnce.Do(func() {
+		cleanupMu.Lock()
+		defer cleanupMu.Unlock()
+		workDir, workDirErr = os.MkdirTemp("", "tac-installer-")
+		if workDirErr == nil {
+			workDirErr = os.Chmod(workDir, 0700)
+		}
+	})
+	return workDir, workDirErr
+}
+
+// Caminho para um arquivo temporário dentro do diretório da execução
+func tempPath(name string) (string, error) {
+	dir, err := getWorkDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, safeFileName(name)), nil
+}
+
+// Subdiretório novo dentro do diretório da execução
+func tempSubdir(prefix string) (string, error) {
+	dir, err := getWorkDir()
+	if err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(dir, prefix)
+}
+
+func cleanupTemp() {
+	cleanupMu.Lock()
+	defer cleanupMu.Unlock()
+	if workDir != "" {
+		os.RemoveAll(workDir)
+		workDir = ""
+	}
+}
+
+// Encerra o programa limpando os arquivos temporários; use no lugar de os.Exit
+func exit(code int) {
+	cleanupTemp()
+	os.Exit(code)
+}
+
+// Limpa os temporários também quando o instalador é interrompido. O contexto
+// devolvido é cancelado no sinal; antes de sair espera a transação do
+// gerenciador de pacotes em andamento terminar.
+func setupSignalCleanup() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		sig := <-c
+		cancel()
+		waitForCommands(5 * time.Second)
+		cleanupTemp()
+		os.Exit(128 + int(sig.(syscall.Signal)))
+	}()
+	return ctx
+}
+
+// Confere, antes de entregar um caminho ao root, que ele está no diretório
+// da execução e que nem ele nem os diretórios acima são links simbólicos ou
+// pertencem a outro usuário
+func verifyPrivate(path string) error {
+	dir, err := getWorkDir()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(path, dir+string(os.PathSeparator)) {
+		return fmt.Errorf(tr("%s está fora do diretório temporário do instalador"), path)
+	}
+
+	for p := path; ; p = filepath.Dir(p) {
+		if err := checkOwnedNoSymlink(p); err != nil {
+			return err
+		}
+		if p == dir {
+			break
+		}
+	}
+
+	fi, _ := os.Lstat(dir)
+	if fi.Mode().Perm() != 0700 {
+		return fmt.Errorf(tr("permissões inseguras em %s: %v"), dir, fi.Mode().Perm())
+	}
+	return nil
+}
+
+func checkOwnedNoSymlink(path string) error {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf(tr("%s é um link simbólico"), path)
+	}
+	if fileOwner(fi) != os.Getuid() {
+		return fmt.Errorf(tr("%s não pertence ao usuário atual"), path)
+	}
+	// Arquivos ficam protegidos pelo diretório 0700 (a umask pode deixá-los 0664)
+	if fi.IsDir() && fi.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf(tr("%s tem permissão de escrita para outros usuários"), path)
+	}
+	return nil
+}
+
+func fileOwner(fi os.FileInfo) int {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return -1
+	}
+	return int(st.Uid)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// --- TERMINAL ---
+
+// Como cada emulador recebe o comando a executar
+type terminal struct {
+	cmd  string
+	args []string // argumentos antes do comando (incluindo o que faz o terminal esperar)
+	// Alguns terminais só aceitam o comando como uma única string
+	singleArg bool
+}
+
+var knownTerminals = []terminal{
+	{cmd: "xdg-terminal-exec"},
+	{cmd: "gnome-terminal", args: []string{"--wait", "--"}},
+	{cmd: "konsole", args: []string{"--nofork", "-e"}},
+	{cmd: "xfce4-terminal", args: []string{"--disable-server", "-x"}},
+	{cmd: "mate-terminal", args: []string{"--disable-factory", "-x"}},
+	{cmd: "ptyxis", args: []string{"--new-window", "-x"}, singleArg: true},
+	{cmd: "alacritty", args: []string{"-e"}},
+	{cmd: "kitty"},
+	{cmd: "foot"},
+	{cmd: "wezterm", args: []string{"start", "--always-new-process", "--"}},
+	{cmd: "xterm", args: []string{"-e"}},
+	{cmd: "tilix", args: []string{"-e"}, singleArg: true},
+	{cmd: "ashyterm", args: []string{"-e"}},
+	{cmd: "zashterminal", args: []string{"-e"}},
+	{cmd: "terminator", args: []string{"-x"}},
+}
+
+// Ordem: xdg-terminal-exec, $TERMINAL e depois a lista de terminais conhecidos
+func findTerminal() *terminal {
+	if _, err := exec.LookPath("xdg-terminal-exec"); err == nil {
+		return &knownTerminals[0]
+	}
+
+	if env := strings.TrimSpace(os.Getenv("TERMINAL")); env != "" {
+		if path, err := exec.LookPath(env); err == nil {
+			for _, t := range knownTerminals {
+				if t.cmd == filepath.Base(path) {
+					t.cmd = path
+					return &t
+				}
+			}
+			return &terminal{cmd: path, args: []string{"-e"}}
+		}
+	}
+
+	for _, t := range knownTerminals[1:] {
+		if _, err := exec.LookPath(t.cmd); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// O comando roda dentro de um sh que grava seu PID ao iniciar e o código
+// de saída ao terminar. Assim sabemos quando e como ele acabou, mesmo em
+// terminais que devolvem o controle antes (servidores como o gnome-terminal).
+const terminalWrapper = `f="$1"; shift; echo $$ > "$f.started"; "$@"; echo $? > "$f.tmp" && mv "$f.tmp" "$f"`
+
+// Tempo máximo para o comando aparecer no terminal depois que o processo do
+// terminal termina sem ter esperado por ele
+const terminalStartTimeout = 30 * time.Second
+
+// Executa o comando num terminal e espera ele terminar, retornando seu código de saída
+func runInTerminal(name string, args ...string) (int, error) {
+	t := findTerminal()
+	if t == nil {
+		return -1, errors.New(tr("nenhum terminal compatível encontrado"))
+	}
+
+	dir, err := tempSubdir("term-")
+	if err != nil {
+		return -1, err
+	}
+	defer os.RemoveAll(dir)
+	exitFile := filepath.Join(dir, "exit-code")
+
+	inner := append([]string{"sh", "-c", terminalWrapper, "sh", exitFile, name}, args...)
+
+	termArgs := append([]string{}, t.args...)
+	if t.singleArg {
+		quoted := make([]string, len(inner))
+		for i, a := range inner {
+			quoted[i] = shellQuote(a)
+		}
+		termArgs = append(termArgs, strings.Join(quoted, " "))
+	} else {
+		termArgs = append(termArgs, inner...)
+	}
+
+	cmd := exec.Command(t.cmd, termArgs...)
+	if err := startLogged(cmd); err != nil {
+		return -1, fmt.Errorf(tr("erro ao abrir o terminal %s: %w"), t.cmd, err)
+	}
+	termDone := make(chan error, 1)
+	go func() { termDone <- cmd.Wait() }()
+
+	var termExited time.Time
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		if data, err := os.ReadFile(exitFile); err == nil {
+			code, err := strconv.Atoi(strings.TrimSpace(string(data)))
+			if err != nil {
+				return -1, fmt.Errorf(tr("código de saída inválido: %q"), data)
+			}
+			slog.Debug("comando no terminal", "args", append([]string{name}, args...), "código", code)
+			return code, nil
+		}
+
+		select {
+		case err := <-termDone:
+			termExited = time.Now()
+			if _, statErr := os.Stat(exitFile + ".started"); statErr != nil && err != nil {
+				return -1, fmt.Errorf(tr("o terminal %s falhou: %w"), t.cmd, err)
+			}
+		case <-ticker.C:
+		}
+
+		if termExited.IsZero() {
+			continue
+		}
+		pid, started := wrapperPid(exitFile + ".started")
+		if !started && time.Since(termExited) > terminalStartTimeout {
+			return -1, fmt.Errorf(tr("o comando não foi iniciado pelo terminal %s"), t.cmd)
+		}
+		// A janela foi fechada antes do fim: o sh morreu sem gravar o código
+		if started && !processAlive(pid) {
+			if _, err := os.Stat(exitFile); err != nil {
+				return -1, errors.New(tr("o terminal foi fechado antes do fim do comando"))
+			}
+		}
+	}
+}
+
+func wrapperPid(path string) (int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		// Arquivo ainda sendo escrito
+		return 0, false
+	}
+	return pid, true
+}
+
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
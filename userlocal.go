@@ -0,0 +1,385 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// --- INSTALAÇÃO LOCAL (SEM ROOT) ---
+
+// Dependências instaláveis via pip; o PyGObject (gi) vem do sistema
+const PipDeps = "dropbox reportlab pygtkspellcheck pyenchant Pillow requests pypdf PyLaTeX"
+
+func getLocalAppDir() string {
+	return filepath.Join(getDataHome(), AppName)
+}
+
+func getLocalLauncherPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "bin", AppName)
+}
+
+func localInstalled() bool {
+	if loadState().Format != FormatLocal {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(getLocalAppDir(), "main.py"))
+	return err == nil
+}
+
+// Verifica se o Python do sistema tem o gi com GTK 4 e libadwaita
+func checkSystemGi() error {
+	script := "import gi; gi.require_version('Gtk', '4.0'); gi.require_version('Adw', '1')"
+	out, err := combinedLogged(exec.Command("python3", "-c", script))
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("%s", msg)
+	}
+	return nil
+}
+
+func installUserLocal(ctx context.Context, release *GithubRelease, version string) bool {
+	if release.TarballUrl == "" {
+		zenityError(tr("Esta versão não possui o código-fonte para instalação local."))
+		return false
+	}
+
+	if err := checkSystemGi(); err != nil {
+		zenityError(trf("A instalação local requer o <b>PyGObject</b> com <b>GTK 4</b> e <b>libadwaita</b> instalados no sistema.\n\n"+
+			"<span size='small'>%s</span>", escapeMarkup(err.Error())))
+		return false
+	}
+
+	tmp, err := tempPath(AppName + "-" + version + ".tar.gz")
+	if err != nil {
+		zenityError(trf("Erro ao criar arquivo temporário:\n%v", err))
+		return false
+	}
+	defer os.Remove(tmp)
+	if err := downloadFile(ctx, release.TarballUrl, tmp); err != nil {
+		showErrorOrCancelled(tr("Erro no download:\n"), err)
+		return false
+	}
+
+	appDir := getLocalAppDir()
+	if err := os.MkdirAll(appDir, 0755); err != nil {
+		zenityError(trf("Erro ao criar %s:\n%v", appDir, err))
+		return false
+	}
+	if err := cleanLocalAppDir(appDir); err != nil {
+		zenityError(trf("Erro ao remover a versão anterior em %s:\n%v", appDir, err))
+		return false
+	}
+	entries, err := extractTarball(tmp, appDir)
+	if err != nil {
+		zenityError(trf("Erro ao extrair o código-fonte:\n%v", err))
+		return false
+	}
+	if err := writeLocalManifest(appDir, entries); err != nil {
+		zenityError(trf("Erro ao registrar os arquivos instalados:\n%v", err))
+		return false
+	}
+
+	venvCtx, cancel := context.WithCancel(ctx)
+	_, done := startPulsateProgress(tr("Instalando..."), trf("Preparando o ambiente Python do %s...\n\nPor favor, aguarde. As dependências serão baixadas via pip.", AppPrettyName), cancel)
+	out, err := setupLocalVenv(venvCtx, appDir)
+	done()
+	cancel()
+	if isCancelled(err) {
+		showCancelled()
+		return false
+	}
+	if err != nil {
+		showCommandError(tr("Erro ao criar o ambiente Python"), out, err)
+		return false
+	}
+
+	if err := writeLocalLauncher(appDir); err != nil {
+		zenityError(trf("Erro ao criar o lançador:\n%v", err))
+		return false
+	}
+	if err := writeLocalDesktopEntry(appDir); err != nil {
+		slog.Warn("falha na integração com o menu de aplicativos", "erro", err)
+	}
+
+	recordInstall(FormatLocal, version)
+	return true
+}
+
+const localManifest = ".tac-installer-files"
+
+// Remove apenas o que a instalação anterior extraiu (listado no manifesto),
+// preservando o venv, o version.txt e quaisquer dados do usuário na pasta
+func cleanLocalAppDir(appDir string) error {
+	data, err := os.ReadFile(filepath.Join(appDir, localManifest))
+	if err != nil {
+		return nil
+	}
+	for _, name := range strings.Split(string(data), "\n") {
+		name = strings.TrimSpace(name)
+		if name == "" || name == "venv" || strings.Contains(name, "/") || name == ".." {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(appDir, name)); err != nil {
+			return err
+		}
+	}
+	return os.Remove(filepath.Join(appDir, localManifest))
+}
+
+func writeLocalManifest(appDir string, entries []string) error {
+	return os.WriteFile(filepath.Join(appDir, localManifest), []byte(strings.Join(entries, "\n")+"\n"), 0644)
+}
+
+// Extrai o tarball do GitHub descartando o diretório raiz "<usuário>-<repo>-<commit>/".
+// Retorna as entradas de primeiro nível criadas em dest.
+func extractTarball(archive, dest string) ([]string, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	destAbs, err := filepath.Abs(dest)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(destAbs, 0755); err != nil {
+		return nil, err
+	}
+	if real, err := filepath.EvalSymlinks(destAbs); err == nil {
+		destAbs = real
+	}
+
+	var entries []string
+	seen := map[string]bool{}
+
+	tarReader := tar.NewReader(gz)
+	for {
+		hdr, err := tarReader.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return entries, err
+		}
+
+		// Nomes absolutos ou com ".." não aparecem num tarball legítimo
+		if filepath.IsAbs(hdr.Name) || hasDotDot(hdr.Name) {
+			return entries, fmt.Errorf(tr("caminho inválido no arquivo: %s"), hdr.Name)
+		}
+		parts := strings.SplitN(hdr.Name, "/", 2)
+		if len(parts) < 2 || parts[1] == "" {
+			continue
+		}
+		target := filepath.Join(destAbs, parts[1])
+		if !strings.HasPrefix(target, destAbs+string(os.PathSeparator)) {
+			return entries, fmt.Errorf(tr("caminho inválido no arquivo: %s"), hdr.Name)
+		}
+		// Um link criado por uma entrada anterior não pode levar a escrita
+		// para fora de dest
+		if !resolvesInside(destAbs, filepath.Dir(target)) {
+			return entries, fmt.Errorf(tr("caminho inválido no arquivo: %s"), hdr.Name)
+		}
+		if fi, err := os.Lstat(target); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			os.Remove(target)
+		}
+		top := strings.SplitN(parts[1], "/", 2)[0]
+		if !seen[top] {
+			seen[top] = true
+			entries = append(entries, top)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return entries, err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return entries, err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode)&0755)
+			if err != nil {
+				return entries, err
+			}
+			if _, err := io.Copy(out, tarReader); err != nil {
+				out.Close()
+				return entries, err
+			}
+			out.Close()
+		case tar.TypeSymlink:
+			if filepath.IsAbs(hdr.Linkname) || !isInside(destAbs, filepath.Join(filepath.Dir(target), hdr.Linkname)) {
+				return entries, fmt.Errorf(tr("link simbólico para fora do diretório no arquivo: %s -> %s"), hdr.Name, hdr.Linkname)
+			}
+			os.Remove(target)
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return entries, err
+			}
+		}
+	}
+}
+
+func hasDotDot(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+func isInside(root, path string) bool {
+	return path == root || strings.HasPrefix(path, root+string(os.PathSeparator))
+}
+
+// Confere o caminho já com os links resolvidos; se ainda não existe, confere
+// o diretório existente mais próximo
+func resolvesInside(root, path string) bool {
+	real, err := filepath.EvalSymlinks(path)
+	if os.IsNotExist(err) && path != root && path != filepath.Dir(path) {
+		return resolvesInside(root, filepath.Dir(path))
+	}
+	return err == nil && isInside(root, real)
+}
+
+func setupLocalVenv(ctx context.Context, appDir string) ([]byte, error) {
+	venv := filepath.Join(appDir, "venv")
+	var log []byte
+
+	if _, err := os.Stat(filepath.Join(venv, "bin", "python")); err != nil {
+		// --system-site-packages dá acesso ao gi instalado pela distribuição
+		out, err := combinedOutput(ctx, userRunner{}, "python3", "-m", "venv", "--system-site-packages", venv)
+		log = append(log, out...)
+		if err != nil {
+			return log, err
+		}
+	}
+
+	args := []string{"-m", "pip", "install", "--upgrade"}
+	args = append(args, localPipDeps(appDir)...)
+	out, err := combinedOutput(ctx, userRunner{}, filepath.Join(venv, "bin", "python"), args...)
+	log = append(log, out...)
+	return log, err
+}
+
+// Usa o requirements.txt do projeto quando existir, sem os pacotes que
+// precisam compilar contra o GTK (fornecidos pelo sistema)
+func localPipDeps(appDir string) []string {
+	f, err := os.Open(filepath.Join(appDir, "requirements.txt"))
+	if err != nil {
+		return strings.Fields(PipDeps)
+	}
+	defer f.Close()
+
+	var deps []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		lower := strings.ToLower(line)
+		if strings.HasPrefix(lower, "pygobject") || strings.HasPrefix(lower, "pycairo") {
+			continue
+		}
+		deps = append(deps, line)
+	}
+	if len(deps) == 0 {
+		return strings.Fields(PipDeps)
+	}
+	return deps
+}
+
+func writeLocalLauncher(appDir string) error {
+	launcher := getLocalLauncherPath()
+	script := fmt.Sprintf("#!/bin/sh\nexec \"%s\" \"%s\" \"$@\"\n",
+		filepath.Join(appDir, "venv", "bin", "python"),
+		filepath.Join(appDir, "main.py"))
+
+	if err := os.MkdirAll(filepath.Dir(launcher), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(launcher, []byte(script), 0755)
+}
+
+func writeLocalDesktopEntry(appDir string) error {
+	icon := FlatpakID
+	if found := findLocalIcon(appDir); found != "" {
+		icon = found
+	}
+
+	entry := fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=%s
+Exec="%s" %%F
+TryExec=%s
+Icon=%s
+Terminal=false
+Categories=Office;WordProcessor;
+`, AppPrettyName, getLocalLauncherPath(), getLocalLauncherPath(), icon)
+
+	dest := getDesktopFilePath()
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(dest, []byte(entry), 0644); err != nil {
+		return err
+	}
+	runLogged(exec.Command("update-desktop-database", filepath.Dir(dest)))
+	return nil
+}
+
+// Procura no código-fonte um ícone com o nome do aplicativo
+func findLocalIcon(appDir string) string {
+	found := ""
+	filepath.WalkDir(appDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || found != "" {
+			return nil
+		}
+		if d.IsDir() && d.Name() == "venv" {
+			return filepath.SkipDir
+		}
+		name := strings.ToLower(d.Name())
+		if (strings.HasPrefix(name, strings.ToLower(FlatpakID)) || strings.HasPrefix(name, AppName)) &&
+			(strings.HasSuffix(name, ".svg") || strings.HasSuffix(name, ".png")) {
+			found = path
+		}
+		return nil
+	})
+	return found
+}
+
+func uninstallUserLocal() bool {
+	appDir := getLocalAppDir()
+	if _, err := os.Stat(filepath.Join(appDir, "main.py")); err != nil {
+		return false
+	}
+	if err := cleanLocalAppDir(appDir); err != nil {
+		return false
+	}
+	os.RemoveAll(filepath.Join(appDir, "venv"))
+	os.Remove(appDir)
+	os.Remove(getLocalLauncherPath())
+	os.Remove(getDesktopFilePath())
+	runLogged(exec.Command("update-desktop-database", filepath.Dir(getDesktopFilePath())))
+	return true
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type tarEntry struct {
+	name     string
+	linkname string // != "" cria um link simbólico
+	body     string
+}
+
+func writeTestTarball(t *testing.T, entries []tarEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "src.tar.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.body))}
+		if e.linkname != "" {
+			hdr = &tar.Header{Name: e.name, Linkname: e.linkname, Mode: 0777, Typeflag: tar.TypeSymlink}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Nada do tarball pode ser gravado fora de dest, nem por "..", nem por nomes
+// absolutos, nem através de links simbólicos
+func TestExtractTarballHostile(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		// Link já existente em dest (ex.: deixado por outra versão) apontando
+		// para fora
+		preLink string
+	}{
+		{"pai no meio", []tarEntry{{name: "tac-writer-1.0/../../evil", body: "x"}}, ""},
+		{"pai no início", []tarEntry{{name: "../evil", body: "x"}}, ""},
+		{"pai no subdiretório", []tarEntry{{name: "tac-writer-1.0/a/../../../evil", body: "x"}}, ""},
+		{"absoluto", []tarEntry{{name: "/tmp/evil", body: "x"}}, ""},
+		{"link para fora", []tarEntry{{name: "tac-writer-1.0/link", linkname: "../../.."}}, ""},
+		{"link absoluto", []tarEntry{{name: "tac-writer-1.0/link", linkname: "/"}}, ""},
+		{"escrita por link do tarball", []tarEntry{
+			{name: "tac-writer-1.0/link", linkname: "../outside"},
+			{name: "tac-writer-1.0/link/evil", body: "x"},
+		}, ""},
+		{"escrita por link existente", []tarEntry{
+			{name: "tac-writer-1.0/main.py", body: "print()"},
+			{name: "tac-writer-1.0/pre/evil", body: "x"},
+		}, "pre"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dest := filepath.Join(root, "dest")
+			outside := filepath.Join(root, "outside")
+			for _, dir := range []string{dest, outside} {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.preLink != "" {
+				if err := os.Symlink(outside, filepath.Join(dest, tt.preLink)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := extractTarball(writeTestTarball(t, tt.entries), dest)
+			if err == nil {
+				t.Error("tarball aceito sem erro")
+			}
+			for _, dir := range []string{root, filepath.Dir(root), "/tmp"} {
+				if _, err := os.Lstat(filepath.Join(dir, "evil")); err == nil {
+					t.Errorf("arquivo gravado fora de dest: %s", filepath.Join(dir, "evil"))
+				}
+			}
+			filepath.WalkDir(outside, func(path string, d fs.DirEntry, err error) error {
+				if err == nil && path != outside {
+					t.Errorf("arquivo gravado fora de dest: %s", path)
+				}
+				return nil
+			})
+		})
+	}
+}
+
+// O diretório raiz do tarball é descartado e links internos são mantidos
+func TestExtractTarball(t *testing.T) {
+	archive := writeTestTarball(t, []tarEntry{
+		{name: "tac-writer-1.0/main.py", body: "print()"},
+		{name: "tac-writer-1.0/data/icon.svg", body: "<svg/>"},
+		{name: "tac-writer-1.0/icon.svg", linkname: "data/icon.svg"},
+	})
+	dest := filepath.Join(t.TempDir(), "dest")
+
+	entries, err := extractTarball(archive, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(entries, ","); got != "main.py,data,icon.svg" {
+		t.Errorf("entradas = %q", got)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "icon.svg")); err != nil || string(data) != "<svg/>" {
+		t.Errorf("link interno: %q, %v", data, err)
+	}
+}